@@ -4,8 +4,9 @@ go 1.23.4
 
 require (
 	github.com/ethereum/go-ethereum v1.15.10
-	github.com/mark3labs/mcp-go v0.23.1
+	github.com/mark3labs/mcp-go v0.32.0
 	github.com/shopspring/decimal v1.4.0
+	golang.org/x/sync v0.11.0
 )
 
 require (
@@ -30,7 +31,6 @@ require (
 	github.com/tklauser/go-sysconf v0.3.12 // indirect
 	github.com/tklauser/numcpus v0.6.1 // indirect
 	golang.org/x/crypto v0.35.0 // indirect
-	golang.org/x/sync v0.11.0 // indirect
 	golang.org/x/sys v0.30.0 // indirect
 	rsc.io/tmplfunc v0.0.3 // indirect
 )
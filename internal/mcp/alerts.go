@@ -0,0 +1,59 @@
+package mcp
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/thewhitewizard/thegraph-mcp-server/internal/watchlist"
+)
+
+const alertLogger = "voucherWatchlist"
+
+// AlertNotifier sends the voucher alerts as MCP logging notifications to the clients whose level allows them
+// and, when webhookURL is set, POSTs them as a JSON array to the webhook. A failed POST is returned so that
+// the watchlist raises the alerts again
+func AlertNotifier(logs *LogNotifier, webhookURL string) func([]watchlist.Alert) error {
+	httpClient := &http.Client{Timeout: defaulHttpTimeout}
+
+	return func(alerts []watchlist.Alert) error {
+		for _, alert := range alerts {
+			log.Printf("Voucher alert: %s", alert.Message)
+
+			logs.Notify(alertLevel(alert.Kind), alertLogger, alert)
+		}
+
+		if webhookURL == "" {
+			return nil
+		}
+
+		body, err := json.Marshal(alerts)
+		if err != nil {
+			return fmt.Errorf("failed to encode voucher alerts: %w", err)
+		}
+
+		resp, err := httpClient.Post(webhookURL, "application/json", bytes.NewReader(body))
+		if err != nil {
+			return fmt.Errorf("failed to post voucher alerts: %w", err)
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+			return fmt.Errorf("failed to post voucher alerts: webhook answered %s", resp.Status)
+		}
+
+		return nil
+	}
+}
+
+// alertLevel is the logging level of an alert, an expiring voucher still has to be used
+func alertLevel(kind string) mcp.LoggingLevel {
+	if kind == watchlist.AlertExpiring {
+		return mcp.LoggingLevelWarning
+	}
+
+	return mcp.LoggingLevelNotice
+}
@@ -5,30 +5,601 @@ import (
 	"fmt"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/thewhitewizard/thegraph-mcp-server/internal/watchlist"
 	"github.com/thewhitewizard/thegraph-mcp-server/pkg/chain"
 	"github.com/thewhitewizard/thegraph-mcp-server/pkg/thegraph"
 )
 
 // Handler functions
-func handleGetVouchers(_ context.Context, request mcp.CallToolRequest, client *thegraph.Client) (*mcp.CallToolResult, error) {
-	vouchers, err := client.GetVouchers()
+func handleGetVouchers(ctx context.Context, request mcp.CallToolRequest, client *thegraph.Client) (*mcp.CallToolResult, error) {
+	client, err := pinBlock(request, client)
 	if err != nil {
-		return nil, err
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	filter, err := parseVoucherFilter(request)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	cursor, _ := request.GetArguments()["cursor"].(string)
+	limit := mcp.ParseInt(request, "limit", 0)
+
+	page, err := client.GetVouchersPage(ctx, filter, cursor, limit)
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("failed to fetch vouchers", err), nil
+	}
+	return mcp.NewToolResultText(formatPage(page, formatVoucher)), nil
+}
+
+func handleGetVoucher(ctx context.Context, request mcp.CallToolRequest, client *thegraph.Client) (*mcp.CallToolResult, error) {
+	client, err := pinBlock(request, client)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	id, _ := request.GetArguments()["id"].(string)
+
+	voucher, err := client.GetVoucher(ctx, id)
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("failed to fetch voucher", err), nil
+	}
+
+	return mcp.NewToolResultText(formatVoucherDetail(voucher)), nil
+}
+
+func handleListVoucherTypes(ctx context.Context, request mcp.CallToolRequest, client *thegraph.Client) (*mcp.CallToolResult, error) {
+	client, err := pinBlock(request, client)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	countActive := mcp.ParseBoolean(request, "countActiveVouchers", false)
+
+	voucherTypes, err := client.GetVoucherTypes(ctx, countActive)
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("failed to fetch voucher types", err), nil
 	}
 	result := ""
-	owner, _ := request.Params.Arguments["owner"].(string)
 
-	for _, v := range vouchers.Data.Vouchers {
-		if owner == "" || strings.EqualFold(owner, v.Owner.ID) {
-			result += formatVoucher(v) + "\n"
+	for _, voucherType := range voucherTypes {
+		result += formatVoucherType(voucherType) + "\n"
+	}
+
+	return mcp.NewToolResultText(result), nil
+}
+
+func handleGetDeals(ctx context.Context, request mcp.CallToolRequest, client *thegraph.Client) (*mcp.CallToolResult, error) {
+	client, err := pinBlock(request, client)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	if id, _ := request.GetArguments()["id"].(string); id != "" {
+		deal, err := client.GetDeal(ctx, id)
+		if err != nil {
+			return mcp.NewToolResultErrorFromErr("failed to fetch deal", err), nil
 		}
+
+		return mcp.NewToolResultText(formatDeal(deal)), nil
+	}
+
+	filter, err := parseDealFilter(request)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	cursor, _ := request.GetArguments()["cursor"].(string)
+	limit := mcp.ParseInt(request, "limit", 0)
+
+	page, err := client.GetDealsPage(ctx, filter, cursor, limit)
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("failed to fetch deals", err), nil
+	}
+	return mcp.NewToolResultText(formatPage(page, formatDeal)), nil
+}
+
+func handleGetTask(ctx context.Context, request mcp.CallToolRequest, client *thegraph.Client, chainClient *chain.Client) (*mcp.CallToolResult, error) {
+	client, err := pinBlock(request, client)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	id, _ := request.GetArguments()["id"].(string)
+
+	task, err := client.GetTask(ctx, id)
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("failed to fetch task", err), nil
+	}
+
+	result := formatTask(task)
+	now := time.Now()
+
+	// A task read at a past block is diagnosed against the time of that block
+	if !client.Block().IsLatest() {
+		meta, err := client.GetMeta(ctx, thegraph.PocoSubgraph)
+		if err != nil {
+			return mcp.NewToolResultErrorFromErr("failed to fetch block", err), nil
+		}
+
+		if !meta.Block.Timestamp.IsZero() {
+			now = meta.Block.Timestamp.Time
+		}
+		result += fmt.Sprintf("Block=%s Time=%s\n", meta.Block.Number, now.Format("2006-01-02 15:04:05 MST"))
+	} else if block, err := chainClient.CurrentBlock(ctx); err == nil {
+		if blockTime, err := chainClient.CurrentBlockTime(ctx); err == nil {
+			now = blockTime
+		}
+		result += fmt.Sprintf("Current block=%d Time=%s\n", block, now.Format("2006-01-02 15:04:05 MST"))
+	} else {
+		result += fmt.Sprintf("Current block unavailable (%v), using local time\n", err)
+	}
+
+	result += "Diagnosis: " + diagnoseTask(task, now)
+
+	return mcp.NewToolResultText(result), nil
+}
+
+func handleGetTasks(ctx context.Context, request mcp.CallToolRequest, client *thegraph.Client) (*mcp.CallToolResult, error) {
+	client, err := pinBlock(request, client)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	filter := thegraph.TaskFilter{}
+	filter.Deal, _ = request.GetArguments()["deal"].(string)
+	filter.Status, _ = request.GetArguments()["status"].(string)
+
+	cursor, _ := request.GetArguments()["cursor"].(string)
+	limit := mcp.ParseInt(request, "limit", 0)
+
+	page, err := client.GetTasksPage(ctx, filter, cursor, limit)
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("failed to fetch tasks", err), nil
+	}
+	return mcp.NewToolResultText(formatPage(page, formatTask)), nil
+}
+
+func handleListApps(ctx context.Context, request mcp.CallToolRequest, client *thegraph.Client) (*mcp.CallToolResult, error) {
+	return handleListAssets(ctx, request, client, (*thegraph.Client).GetAppsPage, formatApp, "apps")
+}
+
+func handleGetApp(ctx context.Context, request mcp.CallToolRequest, client *thegraph.Client) (*mcp.CallToolResult, error) {
+	return handleGetAsset(ctx, request, client, (*thegraph.Client).GetApp, formatApp, "app")
+}
+
+func handleListDatasets(ctx context.Context, request mcp.CallToolRequest, client *thegraph.Client) (*mcp.CallToolResult, error) {
+	return handleListAssets(ctx, request, client, (*thegraph.Client).GetDatasetsPage, formatDataset, "datasets")
+}
+
+func handleGetDataset(ctx context.Context, request mcp.CallToolRequest, client *thegraph.Client) (*mcp.CallToolResult, error) {
+	return handleGetAsset(ctx, request, client, (*thegraph.Client).GetDataset, formatDataset, "dataset")
+}
+
+func handleListWorkerpools(ctx context.Context, request mcp.CallToolRequest, client *thegraph.Client) (*mcp.CallToolResult, error) {
+	return handleListAssets(ctx, request, client, (*thegraph.Client).GetWorkerpoolsPage, formatWorkerpool, "workerpools")
+}
+
+func handleGetWorkerpool(ctx context.Context, request mcp.CallToolRequest, client *thegraph.Client) (*mcp.CallToolResult, error) {
+	return handleGetAsset(ctx, request, client, (*thegraph.Client).GetWorkerpool, formatWorkerpool, "workerpool")
+}
+
+func handleGetWorkerpoolOrders(ctx context.Context, request mcp.CallToolRequest, client *thegraph.Client) (*mcp.CallToolResult, error) {
+	client, err := pinBlock(request, client)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	maxPrice, err := parseAmount(request, "maxPrice")
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
 	}
 
+	filter := thegraph.WorkerpoolOrderFilter{MaxPrice: maxPrice}
+	filter.Category, _ = request.GetArguments()["category"].(string)
+	filter.Workerpool, _ = request.GetArguments()["workerpool"].(string)
+
+	cursor, _ := request.GetArguments()["cursor"].(string)
+	limit := mcp.ParseInt(request, "limit", 0)
+
+	page, err := client.GetWorkerpoolOrdersPage(ctx, filter, cursor, limit)
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("failed to fetch workerpool orders", err), nil
+	}
+
+	return mcp.NewToolResultText(formatPage(page, formatWorkerpoolOrder)), nil
+}
+
+func handleGetRequestOrders(ctx context.Context, request mcp.CallToolRequest, client *thegraph.Client) (*mcp.CallToolResult, error) {
+	client, err := pinBlock(request, client)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	filter := thegraph.RequestOrderFilter{}
+	filter.Requester, _ = request.GetArguments()["requester"].(string)
+	filter.App, _ = request.GetArguments()["app"].(string)
+	filter.Category, _ = request.GetArguments()["category"].(string)
+
+	cursor, _ := request.GetArguments()["cursor"].(string)
+	limit := mcp.ParseInt(request, "limit", 0)
+
+	page, err := client.GetRequestOrdersPage(ctx, filter, cursor, limit)
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("failed to fetch request orders", err), nil
+	}
+
+	return mcp.NewToolResultText(formatPage(page, formatRequestOrder)), nil
+}
+
+func handleGetAppOrders(ctx context.Context, request mcp.CallToolRequest, client *thegraph.Client) (*mcp.CallToolResult, error) {
+	client, err := pinBlock(request, client)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	app, _ := request.GetArguments()["app"].(string)
+	cursor, _ := request.GetArguments()["cursor"].(string)
+	limit := mcp.ParseInt(request, "limit", 0)
+
+	page, err := client.GetAppOrdersPage(ctx, app, cursor, limit)
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("failed to fetch app orders", err), nil
+	}
+
+	return mcp.NewToolResultText(formatPage(page, formatAppOrder)), nil
+}
+
+func handleGetDatasetOrders(ctx context.Context, request mcp.CallToolRequest, client *thegraph.Client) (*mcp.CallToolResult, error) {
+	client, err := pinBlock(request, client)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	dataset, _ := request.GetArguments()["dataset"].(string)
+	cursor, _ := request.GetArguments()["cursor"].(string)
+	limit := mcp.ParseInt(request, "limit", 0)
+
+	page, err := client.GetDatasetOrdersPage(ctx, dataset, cursor, limit)
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("failed to fetch dataset orders", err), nil
+	}
+
+	return mcp.NewToolResultText(formatPage(page, formatDatasetOrder)), nil
+}
+
+func handleGetCheapestWorkerpoolOrder(ctx context.Context, request mcp.CallToolRequest, client *thegraph.Client) (*mcp.CallToolResult, error) {
+	client, err := pinBlock(request, client)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	category, _ := request.GetArguments()["category"].(string)
+	requester, _ := request.GetArguments()["requester"].(string)
+
+	order, err := client.CheapestWorkerpoolOrder(ctx, category, requester)
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("failed to find a workerpool order", err), nil
+	}
+
+	result := fmt.Sprintf("Cheapest open workerpool order for category %s: %s per task\n%s",
+		category, order.WorkerpoolPrice, formatWorkerpoolOrder(order))
+
 	return mcp.NewToolResultText(result), nil
 }
 
+func handleGetAccountActivity(ctx context.Context, request mcp.CallToolRequest, client *thegraph.Client, chainClient *chain.Client) (*mcp.CallToolResult, error) {
+	client, err := pinBlock(request, client)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	wallet, _ := request.GetArguments()["wallet"].(string)
+	if !chain.IsValidEthereumAddressWithChecksum(wallet) {
+		return mcp.NewToolResultError("invalid wallet address " + wallet), nil
+	}
+
+	limit := mcp.ParseInt(request, "limit", defaultActivityLimit)
+
+	activity, err := client.GetAccountActivity(ctx, wallet, limit)
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("failed to fetch account activity", err), nil
+	}
+
+	balanceXRLC := chainClient.GetBalance(ctx, wallet, chain.DECIMAL_18)
+	balanceSRLC := chainClient.GetBalanceForToken(ctx, wallet, chain.BELLECOUR_PROXY_ADDR, chain.DECIMAL_9)
+	balanceLRLC := chainClient.GetLockRLCBalance(ctx, wallet, chain.BELLECOUR_PROXY_ADDR, chain.DECIMAL_9)
+
+	result := fmt.Sprintf("Wallet %s\nBalances: xRLC=%s, sRLC:%s, lockRLC=%s\n", wallet, balanceXRLC, balanceSRLC, balanceLRLC)
+
+	// The balances are read from the chain, only the subgraph activity is pinned
+	result += fmt.Sprintf("Balances as of the latest block, activity as of block %s\n", client.Block())
+	result += formatAccountActivity(activity)
+
+	return mcp.NewToolResultText(result), nil
+}
+
+func handleGetIndexingStatus(ctx context.Context, _ mcp.CallToolRequest, client *thegraph.Client, chainClient *chain.Client) (*mcp.CallToolResult, error) {
+	head, err := chainClient.CurrentBlock(ctx)
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("failed to fetch current block", err), nil
+	}
+
+	result := fmt.Sprintf("Chain head: block=%d", head)
+
+	// Without the head time only the lag in blocks is reported
+	headTime, err := chainClient.CurrentBlockTime(ctx)
+	if err == nil {
+		result += " Time=" + headTime.Format("2006-01-02 15:04:05 MST")
+	}
+	result += "\n"
+
+	for _, endpoint := range client.Subgraphs() {
+		meta, err := client.GetMeta(ctx, endpoint)
+		if err != nil {
+			result += fmt.Sprintf("%s: unavailable (%v)\n", endpoint, err)
+			continue
+		}
+
+		result += formatIndexingStatus(endpoint, meta, head, headTime)
+	}
+
+	return mcp.NewToolResultText(result), nil
+}
+
+func handleDescribeSubgraph(ctx context.Context, request mcp.CallToolRequest, client *thegraph.Client) (*mcp.CallToolResult, error) {
+	subgraph, _ := request.GetArguments()["subgraph"].(string)
+	name, _ := request.GetArguments()["entity"].(string)
+
+	schema, err := client.GetSchema(ctx, subgraph)
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("failed to fetch subgraph schema", err), nil
+	}
+
+	if name != "" {
+		entity, err := schema.Entity(name)
+		if err != nil {
+			return mcp.NewToolResultErrorFromErr("failed to describe "+name, err), nil
+		}
+
+		return mcp.NewToolResultText(formatEntity(entity)), nil
+	}
+
+	entities := schema.Entities()
+	result := fmt.Sprintf("Subgraph %s: %d entities\n", subgraph, len(entities))
+
+	for _, entity := range entities {
+		result += formatEntity(entity)
+	}
+
+	return mcp.NewToolResultText(result), nil
+}
+
+func handleGetCacheStats(_ context.Context, _ mcp.CallToolRequest, client *thegraph.Client) (*mcp.CallToolResult, error) {
+	stats, found := client.CacheStats()
+	if !found {
+		return mcp.NewToolResultText("TheGraph cache is disabled"), nil
+	}
+
+	result := fmt.Sprintf("Entries=%d Hits=%d Misses=%d Coalesced=%d Evictions=%d Invalidations=%d",
+		stats.Entries, stats.Hits, stats.Misses, stats.Coalesced, stats.Evictions, stats.Invalidations)
+
+	return mcp.NewToolResultText(result), nil
+}
+
+func handleVoucherStats(ctx context.Context, request mcp.CallToolRequest, client *thegraph.Client) (*mcp.CallToolResult, error) {
+	client, err := pinBlock(request, client)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	filter, err := parseVoucherFilter(request)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	// Fully consumed vouchers count in the utilisation
+	filter.IncludeZeroBalance = mcp.ParseBoolean(request, "includeZeroBalance", true)
+
+	groupBy, err := parseGroupBy(request)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	stats, err := client.GetVoucherStats(ctx, filter)
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("failed to aggregate vouchers", err), nil
+	}
+
+	limit := mcp.ParseInt(request, "limit", defaultOwnersLimit)
+
+	return mcp.NewToolResultText(formatVoucherStats(stats, groupBy, limit)), nil
+}
+
+func handleWatchVouchers(_ context.Context, request mcp.CallToolRequest, w *watchlist.Watchlist) (*mcp.CallToolResult, error) {
+	owner, _ := request.GetArguments()["owner"].(string)
+	voucher, _ := request.GetArguments()["voucher"].(string)
+
+	if owner == "" && voucher == "" {
+		return mcp.NewToolResultError("owner or voucher is required"), nil
+	}
+
+	if owner != "" {
+		if err := w.WatchOwner(owner); err != nil {
+			return mcp.NewToolResultErrorFromErr("failed to watch "+owner, err), nil
+		}
+	}
+
+	if voucher != "" {
+		if err := w.WatchVoucher(voucher); err != nil {
+			return mcp.NewToolResultErrorFromErr("failed to watch "+voucher, err), nil
+		}
+	}
+
+	return mcp.NewToolResultText(formatWatchlist(w)), nil
+}
+
+func handleUnwatchVouchers(_ context.Context, request mcp.CallToolRequest, w *watchlist.Watchlist) (*mcp.CallToolResult, error) {
+	address, _ := request.GetArguments()["address"].(string)
+
+	if err := w.Unwatch(address); err != nil {
+		return mcp.NewToolResultErrorFromErr("failed to unwatch "+address, err), nil
+	}
+
+	return mcp.NewToolResultText(formatWatchlist(w)), nil
+}
+
+func handleGetWatchlist(_ context.Context, _ mcp.CallToolRequest, w *watchlist.Watchlist) (*mcp.CallToolResult, error) {
+	return mcp.NewToolResultText(formatWatchlist(w)), nil
+}
+
+func handleTemplate(ctx context.Context, request mcp.CallToolRequest, client *thegraph.Client, template thegraph.Template) (*mcp.CallToolResult, error) {
+	data, err := template.Run(ctx, client, request.GetArguments())
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("failed to run "+template.Name, err), nil
+	}
+
+	return mcp.NewToolResultText(string(data)), nil
+}
+
+func handleListEntities(ctx context.Context, request mcp.CallToolRequest, client *thegraph.Client, subgraph string, entity thegraph.Entity) (*mcp.CallToolResult, error) {
+	client, err := pinBlock(request, client)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	where, err := parseVariables(request.GetArguments()["where"])
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("failed to parse where filter", err), nil
+	}
+
+	if where == nil {
+		where = make(map[string]interface{})
+	}
+
+	for _, field := range equalityFilters(entity) {
+		value, found := request.GetArguments()[field.Name]
+		if !found || value == nil {
+			continue
+		}
+
+		// TheGraph stores addresses and hashes in lower case
+		if s, ok := value.(string); ok && strings.HasPrefix(s, "0x") {
+			value = strings.ToLower(s)
+		}
+		where[field.Name] = value
+	}
+
+	orderBy, _ := request.GetArguments()["orderBy"].(string)
+	orderDirection, _ := request.GetArguments()["orderDirection"].(string)
+
+	data, err := client.ListEntities(ctx, subgraph, entity, thegraph.EntityQuery{
+		Where:          where,
+		OrderBy:        orderBy,
+		OrderDirection: orderDirection,
+		Limit:          mcp.ParseInt(request, "limit", 0),
+		Skip:           mcp.ParseInt(request, "skip", 0),
+	})
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("failed to list "+entity.Collection, err), nil
+	}
+
+	return mcp.NewToolResultText(string(data)), nil
+}
+
+func handleGetEntity(ctx context.Context, request mcp.CallToolRequest, client *thegraph.Client, subgraph string, entity thegraph.Entity) (*mcp.CallToolResult, error) {
+	id, _ := request.GetArguments()["id"].(string)
+
+	client, err := pinBlock(request, client)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	data, err := client.GetEntity(ctx, subgraph, entity, id)
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("failed to fetch "+entity.Single, err), nil
+	}
+
+	return mcp.NewToolResultText(string(data)), nil
+}
+
+// handleListAssets lists a page of registry assets filtered by the owner and name arguments
+func handleListAssets[T any](
+	ctx context.Context,
+	request mcp.CallToolRequest,
+	client *thegraph.Client,
+	fetch func(*thegraph.Client, context.Context, thegraph.AssetFilter, string, int) (thegraph.Page[T], error),
+	format func(T) string,
+	name string,
+) (*mcp.CallToolResult, error) {
+	client, err := pinBlock(request, client)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	filter := thegraph.AssetFilter{}
+	filter.Owner, _ = request.GetArguments()["owner"].(string)
+	filter.Name, _ = request.GetArguments()["name"].(string)
+
+	cursor, _ := request.GetArguments()["cursor"].(string)
+	limit := mcp.ParseInt(request, "limit", 0)
+
+	page, err := fetch(client, ctx, filter, cursor, limit)
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("failed to fetch "+name, err), nil
+	}
+
+	return mcp.NewToolResultText(formatPage(page, format)), nil
+}
+
+// handleGetAsset fetches a single registry asset by the id argument
+func handleGetAsset[T any](
+	ctx context.Context,
+	request mcp.CallToolRequest,
+	client *thegraph.Client,
+	fetch func(*thegraph.Client, context.Context, string) (T, error),
+	format func(T) string,
+	name string,
+) (*mcp.CallToolResult, error) {
+	client, err := pinBlock(request, client)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	id, _ := request.GetArguments()["id"].(string)
+
+	asset, err := fetch(client, ctx, id)
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("failed to fetch "+name, err), nil
+	}
+
+	return mcp.NewToolResultText(format(asset)), nil
+}
+
+func handleQueryTheGraph(ctx context.Context, request mcp.CallToolRequest, client *thegraph.Client) (*mcp.CallToolResult, error) {
+	client, err := pinBlock(request, client)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	query, _ := request.GetArguments()["query"].(string)
+	endpoint, _ := request.GetArguments()["endpoint"].(string)
+
+	variables, err := parseVariables(request.GetArguments()["variables"])
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	data, err := client.SafeQuery(ctx, endpoint, query, variables)
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("failed to query TheGraph", err), nil
+	}
+
+	return mcp.NewToolResultText(string(data)), nil
+}
+
 func handleGetLastBlock(ctx context.Context, _ mcp.CallToolRequest, client *chain.Client) (*mcp.CallToolResult, error) {
 	block, err := client.CurrentBlock(ctx)
 	if err != nil {
@@ -40,7 +611,7 @@ func handleGetLastBlock(ctx context.Context, _ mcp.CallToolRequest, client *chai
 }
 
 func handleWalletInfo(ctx context.Context, request mcp.CallToolRequest, client *chain.Client) (*mcp.CallToolResult, error) {
-	wallet, _ := request.Params.Arguments["wallet"].(string)
+	wallet, _ := request.GetArguments()["wallet"].(string)
 
 	balanceXRLC := client.GetBalance(ctx, wallet, chain.DECIMAL_18)
 	balanceSRLC := client.GetBalanceForToken(ctx, wallet, chain.BELLECOUR_PROXY_ADDR, chain.DECIMAL_9)
@@ -0,0 +1,89 @@
+package mcp
+
+import (
+	"context"
+	"slices"
+	"sync"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// loggingLevels are the MCP logging levels by increasing severity
+var loggingLevels = []mcp.LoggingLevel{
+	mcp.LoggingLevelDebug,
+	mcp.LoggingLevelInfo,
+	mcp.LoggingLevelNotice,
+	mcp.LoggingLevelWarning,
+	mcp.LoggingLevelError,
+	mcp.LoggingLevelCritical,
+	mcp.LoggingLevelAlert,
+	mcp.LoggingLevelEmergency,
+}
+
+// LogNotifier sends logging notifications to each client at or above the level it set with logging/setLevel,
+// the clients which did not set a level get all of them
+type LogNotifier struct {
+	server   *server.MCPServer
+	sessions sync.Map // session ID -> server.ClientSession
+	leveled  sync.Map // session ID -> struct{}, the sessions which set a level
+}
+
+// NewLogNotifier creates a LogNotifier, its Hooks must be passed to the MCP server and SetServer called
+// once the server is created
+func NewLogNotifier() *LogNotifier {
+	return &LogNotifier{}
+}
+
+// SetServer sets the MCP server sending the notifications
+func (n *LogNotifier) SetServer(s *server.MCPServer) {
+	n.server = s
+}
+
+// Hooks keep track of the client sessions and of the sessions which set a level
+func (n *LogNotifier) Hooks() *server.Hooks {
+	hooks := &server.Hooks{}
+
+	hooks.AddOnRegisterSession(func(_ context.Context, session server.ClientSession) {
+		n.sessions.Store(session.SessionID(), session)
+	})
+	hooks.AddOnUnregisterSession(func(_ context.Context, session server.ClientSession) {
+		n.sessions.Delete(session.SessionID())
+		n.leveled.Delete(session.SessionID())
+	})
+	hooks.AddAfterSetLevel(func(ctx context.Context, _ any, _ *mcp.SetLevelRequest, _ *mcp.EmptyResult) {
+		if session := server.ClientSessionFromContext(ctx); session != nil {
+			n.leveled.Store(session.SessionID(), struct{}{})
+		}
+	})
+
+	return hooks
+}
+
+// Notify sends a logging notification to the initialized clients whose level allows it
+func (n *LogNotifier) Notify(level mcp.LoggingLevel, logger string, data any) {
+	params := map[string]any{
+		"level":  level,
+		"logger": logger,
+		"data":   data,
+	}
+
+	n.sessions.Range(func(id, value any) bool {
+		session := value.(server.ClientSession)
+		if !session.Initialized() {
+			return true
+		}
+
+		if logging, ok := session.(server.SessionWithLogging); ok {
+			if _, leveled := n.leveled.Load(id); leveled &&
+				slices.Index(loggingLevels, level) < slices.Index(loggingLevels, logging.GetLogLevel()) {
+				return true
+			}
+		}
+
+		// A client which does not read its notifications is skipped, as the MCP server does
+		_ = n.server.SendNotificationToSpecificClient(id.(string), "notifications/message", params)
+
+		return true
+	})
+}
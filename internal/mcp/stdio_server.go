@@ -4,8 +4,6 @@ import (
 	"context"
 	"log"
 	"os"
-	"os/signal"
-	"syscall"
 
 	"github.com/mark3labs/mcp-go/server"
 )
@@ -22,12 +20,8 @@ func NewCustomStdioServer(mcpServer *server.MCPServer) *CustomStdioServer {
 	}
 }
 
-// Start begins serving on stdin/stdout
-func (s *CustomStdioServer) Start() error {
-	// Set up context with signal handling for graceful shutdown
-	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
-	defer stop()
-
+// Start begins serving on stdin/stdout until ctx is done
+func (s *CustomStdioServer) Start(ctx context.Context) error {
 	// Start listening on stdin/stdout
 	return s.server.Listen(ctx, os.Stdin, os.Stdout)
 }
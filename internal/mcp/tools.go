@@ -2,25 +2,58 @@ package mcp
 
 import (
 	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
 
 	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/mark3labs/mcp-go/server"
+	"github.com/thewhitewizard/thegraph-mcp-server/internal/watchlist"
 	"github.com/thewhitewizard/thegraph-mcp-server/pkg/chain"
 	"github.com/thewhitewizard/thegraph-mcp-server/pkg/thegraph"
 )
 
-// RegisterTools registers all the TheGraph API tools with the MCP server
-func RegisterTools(s *server.MCPServer, thegraphClient *thegraph.Client, chainClient *chain.Client) {
+// RegisterTools registers all the TheGraph API tools with the MCP server and returns their names
+func RegisterTools(s *server.MCPServer, thegraphClient *thegraph.Client, chainClient *chain.Client) []string {
+	var names []string
+
 	// 1. GetVouchers
 	getVouchers := mcp.NewTool("getVouchers",
 		mcp.WithDescription("Get Vouchers"),
 		mcp.WithString("owner",
 			mcp.Description("The Owner of the voucher (optionnal, return all if empty)"),
 		),
+		mcp.WithString("voucherType",
+			mcp.Description("The voucher type ID (optionnal)"),
+		),
+		mcp.WithString("expirationBefore",
+			mcp.Description("Only vouchers expiring before this date, 2006-01-02 or RFC3339 (optionnal)"),
+		),
+		mcp.WithString("expirationAfter",
+			mcp.Description("Only vouchers expiring after this date, 2006-01-02 or RFC3339 (optionnal)"),
+		),
+		mcp.WithString("minBalance",
+			mcp.Description("Minimum voucher balance (optionnal)"),
+		),
+		mcp.WithString("maxBalance",
+			mcp.Description("Maximum voucher balance (optionnal)"),
+		),
+		mcp.WithBoolean("includeZeroBalance",
+			mcp.Description("Include fully consumed vouchers (optionnal, default false)"),
+		),
+		mcp.WithNumber("limit",
+			mcp.Description("Maximum number of vouchers per page (optionnal, default 100, max 1000)"),
+		),
+		mcp.WithString("cursor",
+			mcp.Description("The nextCursor returned by a previous call to fetch the next page (optionnal)"),
+		),
+		withBlockArgument(),
 	)
 	s.AddTool(getVouchers, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 		return handleGetVouchers(ctx, request, thegraphClient)
 	})
+	names = append(names, getVouchers.Name)
 
 	// 2. GetLastBlock
 	getLastBlockTool := mcp.NewTool("getLastBlock",
@@ -29,6 +62,7 @@ func RegisterTools(s *server.MCPServer, thegraphClient *thegraph.Client, chainCl
 	s.AddTool(getLastBlockTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 		return handleGetLastBlock(ctx, request, chainClient)
 	})
+	names = append(names, getLastBlockTool.Name)
 
 	// 3. getWalletInfo
 	getWalletInfo := mcp.NewTool("getWalletInfo",
@@ -41,4 +75,528 @@ func RegisterTools(s *server.MCPServer, thegraphClient *thegraph.Client, chainCl
 	s.AddTool(getWalletInfo, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 		return handleWalletInfo(ctx, request, chainClient)
 	})
+	names = append(names, getWalletInfo.Name)
+
+	// 4. queryTheGraph
+	queryTheGraph := mcp.NewTool("queryTheGraph",
+		mcp.WithDescription("Run a read-only GraphQL query against a TheGraph subgraph and return the raw JSON data. "+formatQueryLimits(thegraphClient.QueryLimits())),
+		mcp.WithString("query",
+			mcp.Required(),
+			mcp.Description("The GraphQL query document"),
+		),
+		mcp.WithString("endpoint",
+			mcp.Description("The subgraph name, e.g. voucher or poco, or its path under the TheGraph URL, e.g. /iexec-voucher (optionnal)"),
+		),
+		mcp.WithObject("variables",
+			mcp.Description("The GraphQL variables (optionnal)"),
+		),
+		withBlockArgument(),
+	)
+	s.AddTool(queryTheGraph, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return handleQueryTheGraph(ctx, request, thegraphClient)
+	})
+	names = append(names, queryTheGraph.Name)
+
+	// 5. getVoucher
+	getVoucher := mcp.NewTool("getVoucher",
+		mcp.WithDescription("Get a voucher with its eligible assets, authorized accounts and consumption history"),
+		mcp.WithString("id",
+			mcp.Required(),
+			mcp.Description("The voucher address"),
+		),
+		withBlockArgument(),
+	)
+	s.AddTool(getVoucher, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return handleGetVoucher(ctx, request, thegraphClient)
+	})
+	names = append(names, getVoucher.Name)
+
+	// 6. listVoucherTypes
+	listVoucherTypes := mcp.NewTool("listVoucherTypes",
+		mcp.WithDescription("List voucher types with their duration, eligible assets and optionally their number of active vouchers"),
+		mcp.WithBoolean("countActiveVouchers",
+			mcp.Description("Count the active vouchers of each type, this reads all the vouchers and is slow (optionnal, default false)"),
+		),
+		withBlockArgument(),
+	)
+	s.AddTool(listVoucherTypes, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return handleListVoucherTypes(ctx, request, thegraphClient)
+	})
+	names = append(names, listVoucherTypes.Name)
+
+	// 7. getDeals
+	getDeals := mcp.NewTool("getDeals",
+		mcp.WithDescription("Get PoCo deals with their price, category, bot size, status and tasks"),
+		mcp.WithString("id",
+			mcp.Description("The deal ID, other filters are ignored when set (optionnal)"),
+		),
+		mcp.WithString("requester",
+			mcp.Description("The requester of the deals (optionnal)"),
+		),
+		mcp.WithString("app",
+			mcp.Description("The app of the deals (optionnal)"),
+		),
+		mcp.WithString("dataset",
+			mcp.Description("The dataset of the deals (optionnal)"),
+		),
+		mcp.WithString("workerpool",
+			mcp.Description("The workerpool of the deals (optionnal)"),
+		),
+		mcp.WithString("after",
+			mcp.Description("Only deals created after this date, 2006-01-02 or RFC3339 (optionnal)"),
+		),
+		mcp.WithString("before",
+			mcp.Description("Only deals created before this date, 2006-01-02 or RFC3339 (optionnal)"),
+		),
+		mcp.WithNumber("limit",
+			mcp.Description("Maximum number of deals per page (optionnal, default 100, max 1000)"),
+		),
+		mcp.WithString("cursor",
+			mcp.Description("The nextCursor returned by a previous call to fetch the next page (optionnal)"),
+		),
+		withBlockArgument(),
+	)
+	s.AddTool(getDeals, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return handleGetDeals(ctx, request, thegraphClient)
+	})
+	names = append(names, getDeals.Name)
+
+	// 8. getTask
+	getTask := mcp.NewTool("getTask",
+		mcp.WithDescription("Get a PoCo task with its contributions, deadlines, result and a diagnosis against the current block, or the given block"),
+		mcp.WithString("id",
+			mcp.Required(),
+			mcp.Description("The task ID"),
+		),
+		withBlockArgument(),
+	)
+	s.AddTool(getTask, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return handleGetTask(ctx, request, thegraphClient, chainClient)
+	})
+	names = append(names, getTask.Name)
+
+	// 9. getTasks
+	getTasks := mcp.NewTool("getTasks",
+		mcp.WithDescription("Get the PoCo tasks of a deal"),
+		mcp.WithString("deal",
+			mcp.Required(),
+			mcp.Description("The deal ID"),
+		),
+		mcp.WithString("status",
+			mcp.Description("Only tasks with this status (optionnal)"),
+			mcp.Enum("UNSET", "ACTIVE", "REVEALING", "COMPLETED", "FAILED"),
+		),
+		mcp.WithNumber("limit",
+			mcp.Description("Maximum number of tasks per page (optionnal, default 100, max 1000)"),
+		),
+		mcp.WithString("cursor",
+			mcp.Description("The nextCursor returned by a previous call to fetch the next page (optionnal)"),
+		),
+		withBlockArgument(),
+	)
+	s.AddTool(getTasks, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return handleGetTasks(ctx, request, thegraphClient)
+	})
+	names = append(names, getTasks.Name)
+
+	// 10. listApps
+	listApps := mcp.NewTool("listApps",
+		mcp.WithDescription("List the apps registered on Bellecour"),
+		mcp.WithString("owner",
+			mcp.Description("The owner of the apps (optionnal)"),
+		),
+		mcp.WithString("name",
+			mcp.Description("Text contained in the name, ignoring case (optionnal)"),
+		),
+		mcp.WithNumber("limit",
+			mcp.Description("Maximum number of apps per page (optionnal, default 100, max 1000)"),
+		),
+		mcp.WithString("cursor",
+			mcp.Description("The nextCursor returned by a previous call to fetch the next page (optionnal)"),
+		),
+		withBlockArgument(),
+	)
+	s.AddTool(listApps, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return handleListApps(ctx, request, thegraphClient)
+	})
+	names = append(names, listApps.Name)
+
+	// 11. getApp
+	getApp := mcp.NewTool("getApp",
+		mcp.WithDescription("Get a app registered on Bellecour"),
+		mcp.WithString("id",
+			mcp.Required(),
+			mcp.Description("The app address"),
+		),
+		withBlockArgument(),
+	)
+	s.AddTool(getApp, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return handleGetApp(ctx, request, thegraphClient)
+	})
+	names = append(names, getApp.Name)
+
+	// 12. listDatasets
+	listDatasets := mcp.NewTool("listDatasets",
+		mcp.WithDescription("List the datasets registered on Bellecour"),
+		mcp.WithString("owner",
+			mcp.Description("The owner of the datasets (optionnal)"),
+		),
+		mcp.WithString("name",
+			mcp.Description("Text contained in the name, ignoring case (optionnal)"),
+		),
+		mcp.WithNumber("limit",
+			mcp.Description("Maximum number of datasets per page (optionnal, default 100, max 1000)"),
+		),
+		mcp.WithString("cursor",
+			mcp.Description("The nextCursor returned by a previous call to fetch the next page (optionnal)"),
+		),
+		withBlockArgument(),
+	)
+	s.AddTool(listDatasets, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return handleListDatasets(ctx, request, thegraphClient)
+	})
+	names = append(names, listDatasets.Name)
+
+	// 13. getDataset
+	getDataset := mcp.NewTool("getDataset",
+		mcp.WithDescription("Get a dataset registered on Bellecour"),
+		mcp.WithString("id",
+			mcp.Required(),
+			mcp.Description("The dataset address"),
+		),
+		withBlockArgument(),
+	)
+	s.AddTool(getDataset, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return handleGetDataset(ctx, request, thegraphClient)
+	})
+	names = append(names, getDataset.Name)
+
+	// 14. listWorkerpools
+	listWorkerpools := mcp.NewTool("listWorkerpools",
+		mcp.WithDescription("List the workerpools registered on Bellecour"),
+		mcp.WithString("owner",
+			mcp.Description("The owner of the workerpools (optionnal)"),
+		),
+		mcp.WithString("name",
+			mcp.Description("Text contained in the description, ignoring case (optionnal)"),
+		),
+		mcp.WithNumber("limit",
+			mcp.Description("Maximum number of workerpools per page (optionnal, default 100, max 1000)"),
+		),
+		mcp.WithString("cursor",
+			mcp.Description("The nextCursor returned by a previous call to fetch the next page (optionnal)"),
+		),
+		withBlockArgument(),
+	)
+	s.AddTool(listWorkerpools, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return handleListWorkerpools(ctx, request, thegraphClient)
+	})
+	names = append(names, listWorkerpools.Name)
+
+	// 15. getWorkerpool
+	getWorkerpool := mcp.NewTool("getWorkerpool",
+		mcp.WithDescription("Get a workerpool registered on Bellecour"),
+		mcp.WithString("id",
+			mcp.Required(),
+			mcp.Description("The workerpool address"),
+		),
+		withBlockArgument(),
+	)
+	s.AddTool(getWorkerpool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return handleGetWorkerpool(ctx, request, thegraphClient)
+	})
+	names = append(names, getWorkerpool.Name)
+
+	// 16. getWorkerpoolOrders
+	getWorkerpoolOrders := mcp.NewTool("getWorkerpoolOrders",
+		mcp.WithDescription("Get the workerpool orders known to the PoCo subgraph which still have some volume, with their price and remaining volume"),
+		mcp.WithString("category",
+			mcp.Description("The category ID of the orders (optionnal)"),
+		),
+		mcp.WithString("workerpool",
+			mcp.Description("The workerpool of the orders (optionnal)"),
+		),
+		mcp.WithString("maxPrice",
+			mcp.Description("Maximum price per task in RLC (optionnal)"),
+		),
+		mcp.WithNumber("limit",
+			mcp.Description("Maximum number of orders per page (optionnal, default 100, max 1000)"),
+		),
+		mcp.WithString("cursor",
+			mcp.Description("The nextCursor returned by a previous call to fetch the next page (optionnal)"),
+		),
+		withBlockArgument(),
+	)
+	s.AddTool(getWorkerpoolOrders, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return handleGetWorkerpoolOrders(ctx, request, thegraphClient)
+	})
+	names = append(names, getWorkerpoolOrders.Name)
+
+	// 17. getRequestOrders
+	getRequestOrders := mcp.NewTool("getRequestOrders",
+		mcp.WithDescription("Get request orders known to the PoCo subgraph with their max prices and remaining volume"),
+		mcp.WithString("requester",
+			mcp.Description("The requester of the orders (optionnal)"),
+		),
+		mcp.WithString("app",
+			mcp.Description("The app of the orders (optionnal)"),
+		),
+		mcp.WithString("category",
+			mcp.Description("The category ID of the orders (optionnal)"),
+		),
+		mcp.WithNumber("limit",
+			mcp.Description("Maximum number of orders per page (optionnal, default 100, max 1000)"),
+		),
+		mcp.WithString("cursor",
+			mcp.Description("The nextCursor returned by a previous call to fetch the next page (optionnal)"),
+		),
+		withBlockArgument(),
+	)
+	s.AddTool(getRequestOrders, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return handleGetRequestOrders(ctx, request, thegraphClient)
+	})
+	names = append(names, getRequestOrders.Name)
+
+	// 18. getAppOrders
+	getAppOrders := mcp.NewTool("getAppOrders",
+		mcp.WithDescription("Get the orders of an app known to the PoCo subgraph"),
+		mcp.WithString("app",
+			mcp.Required(),
+			mcp.Description("The app address"),
+		),
+		mcp.WithNumber("limit",
+			mcp.Description("Maximum number of orders per page (optionnal, default 100, max 1000)"),
+		),
+		mcp.WithString("cursor",
+			mcp.Description("The nextCursor returned by a previous call to fetch the next page (optionnal)"),
+		),
+		withBlockArgument(),
+	)
+	s.AddTool(getAppOrders, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return handleGetAppOrders(ctx, request, thegraphClient)
+	})
+	names = append(names, getAppOrders.Name)
+
+	// 19. getDatasetOrders
+	getDatasetOrders := mcp.NewTool("getDatasetOrders",
+		mcp.WithDescription("Get the orders of a dataset known to the PoCo subgraph"),
+		mcp.WithString("dataset",
+			mcp.Required(),
+			mcp.Description("The dataset address"),
+		),
+		mcp.WithNumber("limit",
+			mcp.Description("Maximum number of orders per page (optionnal, default 100, max 1000)"),
+		),
+		mcp.WithString("cursor",
+			mcp.Description("The nextCursor returned by a previous call to fetch the next page (optionnal)"),
+		),
+		withBlockArgument(),
+	)
+	s.AddTool(getDatasetOrders, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return handleGetDatasetOrders(ctx, request, thegraphClient)
+	})
+	names = append(names, getDatasetOrders.Name)
+
+	// 20. getCheapestWorkerpoolOrder
+	getCheapestWorkerpoolOrder := mcp.NewTool("getCheapestWorkerpoolOrder",
+		mcp.WithDescription("Find the cheapest workerpool order with remaining volume for a category"),
+		mcp.WithString("category",
+			mcp.Required(),
+			mcp.Description("The category ID"),
+		),
+		mcp.WithString("requester",
+			mcp.Description("The requester, to include orders restricted to it (optionnal)"),
+		),
+		withBlockArgument(),
+	)
+	s.AddTool(getCheapestWorkerpoolOrder, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return handleGetCheapestWorkerpoolOrder(ctx, request, thegraphClient)
+	})
+	names = append(names, getCheapestWorkerpoolOrder.Name)
+
+	// 21. getAccountActivity
+	getAccountActivity := mcp.NewTool("getAccountActivity",
+		mcp.WithDescription("Get a report of a wallet activity: balances, deals as requester and scheduler, tasks as worker, assets, vouchers and transfers"),
+		mcp.WithString("wallet",
+			mcp.Required(),
+			mcp.Description("wallet to fetch activity"),
+		),
+		mcp.WithNumber("limit",
+			mcp.Description("Maximum number of latest items per kind of activity (optionnal, default 10)"),
+		),
+		withBlockArgument(),
+	)
+	s.AddTool(getAccountActivity, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return handleGetAccountActivity(ctx, request, thegraphClient, chainClient)
+	})
+	names = append(names, getAccountActivity.Name)
+
+	// 22. getIndexingStatus
+	getIndexingStatus := mcp.NewTool("getIndexingStatus",
+		mcp.WithDescription("Get the indexing status of each subgraph: lag behind the chain head in blocks and seconds, and indexing errors"),
+	)
+	s.AddTool(getIndexingStatus, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return handleGetIndexingStatus(ctx, request, thegraphClient, chainClient)
+	})
+	names = append(names, getIndexingStatus.Name)
+
+	// 23. describeSubgraph
+	describeSubgraph := mcp.NewTool("describeSubgraph",
+		mcp.WithDescription("Describe the entities of a subgraph schema: fields, relations, where filter operators and orderBy values, to compose queries for queryTheGraph"),
+		mcp.WithString("subgraph",
+			mcp.Required(),
+			mcp.Description("The subgraph name, e.g. voucher or poco, or its path under the TheGraph URL"),
+		),
+		mcp.WithString("entity",
+			mcp.Description("Only describe this entity, e.g. Voucher or vouchers (optionnal)"),
+		),
+	)
+	s.AddTool(describeSubgraph, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return handleDescribeSubgraph(ctx, request, thegraphClient)
+	})
+	names = append(names, describeSubgraph.Name)
+
+	// 24. getCacheStats
+	getCacheStats := mcp.NewTool("getCacheStats",
+		mcp.WithDescription("Get the hit and miss counters of the TheGraph response cache"),
+	)
+	s.AddTool(getCacheStats, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return handleGetCacheStats(ctx, request, thegraphClient)
+	})
+	names = append(names, getCacheStats.Name)
+
+	// 25. voucherStats
+	voucherStats := mcp.NewTool("voucherStats",
+		mcp.WithDescription("Aggregate the vouchers: count, total value, total balance and utilisation grouped by voucher type, owner and expiration week and month (UTC)"),
+		mcp.WithString("owner",
+			mcp.Description("The Owner of the vouchers (optionnal, all owners if empty)"),
+		),
+		mcp.WithString("voucherType",
+			mcp.Description("The voucher type ID (optionnal)"),
+		),
+		mcp.WithString("expirationBefore",
+			mcp.Description("Only vouchers expiring before this date, 2006-01-02 or RFC3339 (optionnal)"),
+		),
+		mcp.WithString("expirationAfter",
+			mcp.Description("Only vouchers expiring after this date, 2006-01-02 or RFC3339 (optionnal)"),
+		),
+		mcp.WithBoolean("includeZeroBalance",
+			mcp.Description("Include fully consumed vouchers (optionnal, default true)"),
+		),
+		mcp.WithString("groupBy",
+			mcp.Description("Comma separated groupings among type, owner, week and month (optionnal, default all)"),
+		),
+		mcp.WithNumber("limit",
+			mcp.Description("Maximum number of owners listed, by decreasing balance (optionnal, default 20)"),
+		),
+		withBlockArgument(),
+	)
+	s.AddTool(voucherStats, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return handleVoucherStats(ctx, request, thegraphClient)
+	})
+	names = append(names, voucherStats.Name)
+
+	return names
+}
+
+// RegisterWatchlistTools registers the tools managing the voucher watchlist and returns their names
+func RegisterWatchlistTools(s *server.MCPServer, w *watchlist.Watchlist) []string {
+	var names []string
+
+	// 1. watchVouchers
+	watchVouchers := mcp.NewTool("watchVouchers",
+		mcp.WithDescription("Add an owner or a voucher to the watchlist, alerts are sent as logging notifications when a watched voucher is about to expire, is fully consumed or its balance drops"),
+		mcp.WithString("owner",
+			mcp.Description("Watch all the vouchers of this owner (optionnal if voucher is set)"),
+		),
+		mcp.WithString("voucher",
+			mcp.Description("Watch this voucher address (optionnal if owner is set)"),
+		),
+	)
+	s.AddTool(watchVouchers, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return handleWatchVouchers(ctx, request, w)
+	})
+	names = append(names, watchVouchers.Name)
+
+	// 2. unwatchVouchers
+	unwatchVouchers := mcp.NewTool("unwatchVouchers",
+		mcp.WithDescription("Remove an owner or a voucher from the watchlist"),
+		mcp.WithString("address",
+			mcp.Required(),
+			mcp.Description("The owner or voucher address"),
+		),
+	)
+	s.AddTool(unwatchVouchers, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return handleUnwatchVouchers(ctx, request, w)
+	})
+	names = append(names, unwatchVouchers.Name)
+
+	// 3. getWatchlist
+	getWatchlist := mcp.NewTool("getWatchlist",
+		mcp.WithDescription("Get the watched owners and vouchers with the alert rules"),
+	)
+	s.AddTool(getWatchlist, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return handleGetWatchlist(ctx, request, w)
+	})
+	names = append(names, getWatchlist.Name)
+
+	return names
+}
+
+// RegisterTemplates registers each persisted query template as a tool whose arguments are the template variables
+func RegisterTemplates(s *server.MCPServer, thegraphClient *thegraph.Client, templates []thegraph.Template) {
+	for _, template := range templates {
+		options := []mcp.ToolOption{mcp.WithDescription(template.Description)}
+
+		for _, variable := range template.Variables {
+			options = append(options, templateArgument(variable))
+		}
+
+		tool := mcp.NewTool(template.Name, options...)
+		s.AddTool(tool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			return handleTemplate(ctx, request, thegraphClient, template)
+		})
+	}
+}
+
+// RegisterEntityTools registers a list and a get tool for each entity of the subgraph schemas, e.g. listDeals
+// and getDeal, and returns their names. A name already generated for another subgraph gets the subgraph name,
+// e.g. listVoucherAccounts, and tools registered afterwards replace the generated tools of the same name
+func RegisterEntityTools(s *server.MCPServer, thegraphClient *thegraph.Client, schemas map[string]thegraph.Schema) []string {
+	var names []string
+
+	for _, subgraph := range slices.Sorted(maps.Keys(schemas)) {
+		for _, entity := range schemas[subgraph].Entities() {
+			list := entityToolName(names, "list", subgraph, entity.Collection)
+			options := append([]mcp.ToolOption{
+				mcp.WithDescription(strings.TrimSpace(fmt.Sprintf("List the %s entities of the %s subgraph. %s", entity.Name, subgraph, entity.Description))),
+			}, entityArguments(entity)...)
+
+			s.AddTool(mcp.NewTool(list, options...), func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+				return handleListEntities(ctx, request, thegraphClient, subgraph, entity)
+			})
+			names = append(names, list)
+
+			if entity.Single == "" {
+				continue
+			}
+
+			get := entityToolName(names, "get", subgraph, entity.Single)
+			tool := mcp.NewTool(get,
+				mcp.WithDescription(fmt.Sprintf("Get a %s entity of the %s subgraph by ID", entity.Name, subgraph)),
+				mcp.WithString("id",
+					mcp.Required(),
+					mcp.Description("The entity ID"),
+				),
+				withBlockArgument(),
+			)
+
+			s.AddTool(tool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+				return handleGetEntity(ctx, request, thegraphClient, subgraph, entity)
+			})
+			names = append(names, get)
+		}
+	}
+
+	return names
 }
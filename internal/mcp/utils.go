@@ -1,17 +1,798 @@
 package mcp
 
 import (
+	"cmp"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"maps"
+	"math"
+	"slices"
 	"strconv"
+	"strings"
 	"time"
 
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/shopspring/decimal"
+	"github.com/thewhitewizard/thegraph-mcp-server/internal/watchlist"
 	"github.com/thewhitewizard/thegraph-mcp-server/pkg/thegraph"
 )
 
-func formatVoucher(v thegraph.Voucher) string {
-	timestamp, _ := strconv.ParseInt(v.Expiration, 10, 64)
-	date := time.Unix(timestamp, 0)
+const (
+	defaultActivityLimit = 10
+	defaultOwnersLimit   = 20
+)
+
+// voucherGroupings are the groupings of the voucherStats tool, in output order
+var voucherGroupings = []string{"type", "owner", "week", "month"}
 
+var (
+	errInvalidVariables = errors.New("variables must be a JSON object")
+	errInvalidDate      = errors.New("dates must be formatted as 2006-01-02 or RFC3339")
+	errInvalidAmount    = errors.New("amounts must be decimal numbers")
+	errInvalidGroupBy   = errors.New("groupBy must list type, owner, week or month")
+	errInvalidBlock     = errors.New("block must be a block number or a 0x prefixed block hash")
+)
+
+func formatVoucher(v thegraph.Voucher) string {
 	return fmt.Sprintf("ID=%s Type=%s Owner=%s Value=%s Balance=%s Expiration=%s",
-		v.ID, v.VoucherType.Desc, v.Owner.ID, v.Value, v.Balance, date.Format("2006-01-02 15:04:05 MST"))
+		v.ID, v.VoucherType.Desc, v.Owner.ID, v.Value, v.Balance, formatDate(v.Expiration))
+}
+
+func formatVoucherDetail(v thegraph.VoucherDetail) string {
+	var sb strings.Builder
+
+	sb.WriteString(formatVoucher(v.Voucher) + "\n")
+	fmt.Fprintf(&sb, "Status: %s\n", voucherStatus(v.Voucher))
+	fmt.Fprintf(&sb, "Voucher type: ID=%s Description=%s Duration=%s\n", v.VoucherType.ID, v.VoucherType.Desc, v.VoucherType.Duration)
+
+	sb.WriteString(formatEligibleAssets(v.VoucherType.EligibleAssets))
+
+	accounts := make([]string, 0, len(v.AuthorizedAccounts))
+	for _, account := range v.AuthorizedAccounts {
+		accounts = append(accounts, account.ID)
+	}
+	fmt.Fprintf(&sb, "Authorized accounts: %s\n", formatList(accounts))
+
+	fmt.Fprintf(&sb, "Consumption history (%d deals):\n", len(v.Deals))
+	for _, deal := range v.Deals {
+		fmt.Fprintf(&sb, "- Deal=%s Date=%s Amount=%s App=%s Dataset=%s Workerpool=%s\n",
+			deal.ID, formatDate(deal.Timestamp), deal.SponsoredAmount, deal.App.ID, deal.Dataset.ID, deal.Workerpool.ID)
+	}
+
+	return sb.String()
+}
+
+func formatVoucherType(v thegraph.VoucherTypeSummary) string {
+	result := fmt.Sprintf("ID=%s Description=%s Duration=%s", v.ID, v.Desc, v.Duration)
+	if v.ActiveVouchers != nil {
+		result += fmt.Sprintf(" ActiveVouchers=%d", *v.ActiveVouchers)
+	}
+
+	return result + "\n" + formatEligibleAssets(v.EligibleAssets)
+}
+
+func formatEligibleAssets(assets []thegraph.Asset) string {
+	var sb strings.Builder
+
+	eligible := map[string][]string{}
+	for _, asset := range assets {
+		eligible[asset.AssetType] = append(eligible[asset.AssetType], asset.ID)
+	}
+
+	for _, assetType := range []string{"app", "dataset", "workerpool"} {
+		fmt.Fprintf(&sb, "Eligible %ss: %s\n", assetType, formatList(eligible[assetType]))
+	}
+
+	return sb.String()
+}
+
+func formatDeal(d thegraph.Deal) string {
+	var sb strings.Builder
+
+	dataset := "none"
+	if d.Dataset != nil {
+		dataset = d.Dataset.ID
+	}
+
+	fmt.Fprintf(&sb, "ID=%s Date=%s Status=%s Category=%s(%s) BotFirst=%s BotSize=%s Trust=%s\n",
+		d.ID, formatDate(d.Timestamp), d.Status(), d.Category.Name, d.Category.ID, d.BotFirst, d.BotSize, d.Trust)
+	fmt.Fprintf(&sb, "Price per task=%s (app=%s, dataset=%s, workerpool=%s)\n",
+		d.Price(), d.AppPrice, d.DatasetPrice, d.WorkerpoolPrice)
+	fmt.Fprintf(&sb, "Requester=%s Beneficiary=%s App=%s Dataset=%s Workerpool=%s\n",
+		d.Requester.ID, d.Beneficiary.ID, d.App.ID, dataset, d.Workerpool.ID)
+	fmt.Fprintf(&sb, "Tasks (%s completed, %s claimed):\n", d.CompletedTasksCount, d.ClaimedTasksCount)
+
+	for _, task := range d.Tasks {
+		fmt.Fprintf(&sb, "- Task=%s Index=%s Status=%s FinalDeadline=%s\n",
+			task.ID, task.Index, task.Status, formatDate(task.FinalDeadline))
+	}
+
+	return sb.String()
+}
+
+func formatTask(t thegraph.Task) string {
+	var sb strings.Builder
+
+	fmt.Fprintf(&sb, "ID=%s Index=%s Status=%s\n", t.ID, t.Index, t.Status)
+
+	if t.Deal != nil {
+		fmt.Fprintf(&sb, "Deal=%s Requester=%s App=%s Workerpool=%s\n",
+			t.Deal.ID, t.Deal.Requester.ID, t.Deal.App.ID, t.Deal.Workerpool.ID)
+	}
+
+	fmt.Fprintf(&sb, "ContributionDeadline=%s RevealDeadline=%s FinalDeadline=%s\n",
+		formatDate(t.ContributionDeadline), formatDate(t.RevealDeadline), formatDate(t.FinalDeadline))
+
+	if location := t.ResultLocation(); location != "" {
+		fmt.Fprintf(&sb, "Result=%s\n", location)
+	}
+
+	fmt.Fprintf(&sb, "Contributions (%d):\n", len(t.Contributions))
+	for _, contribution := range t.Contributions {
+		fmt.Fprintf(&sb, "- Worker=%s Status=%s Date=%s\n",
+			contribution.Worker.ID, contribution.Status, formatDate(contribution.Timestamp))
+	}
+
+	return sb.String()
+}
+
+// diagnoseTask explains where a task stands at the given chain time
+func diagnoseTask(t thegraph.Task, now time.Time) string {
+	deadlinePassed := !t.FinalDeadline.IsZero() && now.After(t.FinalDeadline.Time)
+
+	switch t.Status {
+	case thegraph.TaskStatusUnset:
+		return "task not initialized yet, the workerpool scheduler has not picked it up"
+	case thegraph.TaskStatusActive, thegraph.TaskStatusRevealing:
+		if deadlinePassed {
+			return "final deadline passed without completion, the task can be claimed to refund the requester"
+		}
+
+		if t.Status == thegraph.TaskStatusRevealing {
+			return fmt.Sprintf("consensus reached, waiting for workers to reveal before %s", formatDate(t.RevealDeadline))
+		}
+
+		if len(t.Contributions) == 0 {
+			return fmt.Sprintf("waiting for workers to contribute, final deadline %s", formatDate(t.FinalDeadline))
+		}
+
+		return fmt.Sprintf("%d contributions received, waiting for consensus before %s",
+			len(t.Contributions), formatDate(t.FinalDeadline))
+	case thegraph.TaskStatusCompleted:
+		return "task completed"
+	case thegraph.TaskStatusFailed:
+		return "task failed and was claimed"
+	default:
+		return "unknown task status " + t.Status
+	}
+}
+
+func formatAccountActivity(a thegraph.AccountActivity) string {
+	var sb strings.Builder
+
+	if a.Account != nil {
+		fmt.Fprintf(&sb, "PoCo account: balance=%s frozen=%s\n", a.Account.Balance, a.Account.Frozen)
+	}
+
+	fmt.Fprintf(&sb, "\nDeals as requester (%d latest):\n", len(a.RequestedDeals))
+	for _, deal := range a.RequestedDeals {
+		sb.WriteString(formatDealSummary(deal))
+	}
+
+	fmt.Fprintf(&sb, "\nDeals as scheduler (%d latest):\n", len(a.ScheduledDeals))
+	for _, deal := range a.ScheduledDeals {
+		sb.WriteString(formatDealSummary(deal))
+	}
+
+	fmt.Fprintf(&sb, "\nTasks as worker (%d latest contributions):\n", len(a.Contributions))
+	for _, contribution := range a.Contributions {
+		if contribution.Task == nil {
+			continue
+		}
+		fmt.Fprintf(&sb, "- Task=%s TaskStatus=%s Contribution=%s Date=%s\n",
+			contribution.Task.ID, contribution.Task.Status, contribution.Status, formatDate(contribution.Timestamp))
+	}
+
+	fmt.Fprintf(&sb, "\nAssets owned: %d apps, %d datasets, %d workerpools\n", len(a.Apps), len(a.Datasets), len(a.Workerpools))
+	for _, app := range a.Apps {
+		fmt.Fprintf(&sb, "- App=%s Name=%s\n", app.ID, app.Name)
+	}
+	for _, dataset := range a.Datasets {
+		fmt.Fprintf(&sb, "- Dataset=%s Name=%s\n", dataset.ID, dataset.Name)
+	}
+	for _, workerpool := range a.Workerpools {
+		fmt.Fprintf(&sb, "- Workerpool=%s Description=%s\n", workerpool.ID, workerpool.Description)
+	}
+
+	fmt.Fprintf(&sb, "\nVouchers owned (%d):\n", len(a.Vouchers))
+	for _, voucher := range a.Vouchers {
+		fmt.Fprintf(&sb, "- %s Status=%s\n", formatVoucher(voucher), voucherStatus(voucher))
+	}
+
+	fmt.Fprintf(&sb, "\nRecent transfers (%d):\n", len(a.Transfers))
+	for _, transfer := range a.Transfers {
+		fmt.Fprintf(&sb, "- From=%s To=%s Value=%s Date=%s\n",
+			transfer.From.ID, transfer.To.ID, transfer.Value, formatDate(transfer.Timestamp))
+	}
+
+	return sb.String()
+}
+
+func formatDealSummary(d thegraph.Deal) string {
+	return fmt.Sprintf("- Deal=%s Date=%s Status=%s App=%s Workerpool=%s BotSize=%s Price per task=%s\n",
+		d.ID, formatDate(d.Timestamp), d.Status(), d.App.ID, d.Workerpool.ID, d.BotSize, d.Price())
+}
+
+func voucherStatus(v thegraph.Voucher) string {
+	switch {
+	case v.Expiration.Before(time.Now()):
+		return "expired"
+	case !v.Balance.IsPositive():
+		return "fully consumed"
+	default:
+		return "active"
+	}
+}
+
+func formatList(values []string) string {
+	if len(values) == 0 {
+		return "none"
+	}
+
+	return strings.Join(values, ", ")
+}
+
+func formatIndexingStatus(endpoint string, m thegraph.Meta, head uint64, headTime time.Time) string {
+	blocks, delay := m.Lag(head, headTime)
+
+	result := fmt.Sprintf("%s: Deployment=%s Block=%s Hash=%s Date=%s Lag=%d blocks",
+		endpoint, m.Deployment, m.Block.Number, m.Block.Hash, formatDate(m.Block.Timestamp), blocks)
+	if delay != 0 {
+		result += fmt.Sprintf(" (%d seconds)", int64(delay.Seconds()))
+	}
+
+	if m.HasIndexingErrors {
+		result += " WARNING: subgraph has indexing errors, data may be incomplete"
+	}
+
+	return result + "\n"
+}
+
+func formatVoucherStats(s thegraph.VoucherStats, groupBy []string, limit int) string {
+	var sb strings.Builder
+
+	fmt.Fprintf(&sb, "Vouchers: %s\n", formatVoucherTotals(s.Total))
+
+	if slices.Contains(groupBy, "type") {
+		sb.WriteString("By voucher type:\n")
+		// Voucher type IDs are numbers
+		types := slices.SortedFunc(maps.Keys(s.ByType), func(a, b string) int {
+			return cmp.Or(cmp.Compare(len(a), len(b)), cmp.Compare(a, b))
+		})
+		for _, id := range types {
+			fmt.Fprintf(&sb, "- %s (%s): %s\n", id, s.Types[id], formatVoucherTotals(s.ByType[id]))
+		}
+	}
+
+	if slices.Contains(groupBy, "owner") {
+		owners := slices.SortedFunc(maps.Keys(s.ByOwner), func(a, b string) int {
+			return cmp.Or(s.ByOwner[b].Balance.Cmp(s.ByOwner[a].Balance), cmp.Compare(a, b))
+		})
+
+		fmt.Fprintf(&sb, "By owner (%d owners, by decreasing balance):\n", len(owners))
+		for i, owner := range owners {
+			if limit > 0 && i == limit {
+				fmt.Fprintf(&sb, "- ... and %d more owners\n", len(owners)-limit)
+				break
+			}
+			fmt.Fprintf(&sb, "- %s: %s\n", owner, formatVoucherTotals(s.ByOwner[owner]))
+		}
+	}
+
+	if slices.Contains(groupBy, "week") {
+		sb.WriteString("By expiration week:\n")
+		for _, week := range slices.Sorted(maps.Keys(s.ByWeek)) {
+			fmt.Fprintf(&sb, "- %s: %s\n", week, formatVoucherTotals(s.ByWeek[week]))
+		}
+	}
+
+	if slices.Contains(groupBy, "month") {
+		sb.WriteString("By expiration month:\n")
+		for _, month := range slices.Sorted(maps.Keys(s.ByMonth)) {
+			fmt.Fprintf(&sb, "- %s: %s\n", month, formatVoucherTotals(s.ByMonth[month]))
+		}
+	}
+
+	return sb.String()
+}
+
+func formatVoucherTotals(t thegraph.VoucherTotals) string {
+	return fmt.Sprintf("Count=%d Value=%s Balance=%s Consumed=%s Utilisation=%s%%",
+		t.Count, t.Value, t.Balance, t.Consumed(), t.Utilisation().Shift(2).StringFixed(2))
+}
+
+func formatWatchlist(w *watchlist.Watchlist) string {
+	owners, vouchers := w.Watched()
+	rules := w.Rules()
+
+	expiry := "disabled"
+	if rules.ExpiryWithin > 0 {
+		expiry = fmt.Sprintf("within %g days", rules.ExpiryWithin.Hours()/24)
+	}
+
+	drop := "disabled"
+	if rules.BalanceDrop.IsPositive() {
+		drop = "more than " + rules.BalanceDrop.String()
+	}
+
+	return fmt.Sprintf("Watched owners: %s\nWatched vouchers: %s\nAlerts: fully consumed, expiring %s, balance drop %s\n",
+		formatList(owners), formatList(vouchers), expiry, drop)
+}
+
+func formatEntity(e thegraph.Entity) string {
+	var sb strings.Builder
+
+	fmt.Fprintf(&sb, "%s (list: %s, get: %s)", e.Name, e.Collection, e.Single)
+	if e.Description != "" {
+		sb.WriteString(" " + e.Description)
+	}
+
+	fields := make([]string, 0, len(e.Fields))
+	for _, field := range e.Fields {
+		if field.Relation != "" {
+			fields = append(fields, fmt.Sprintf("%s -> %s", field.Name, field.Type))
+		} else {
+			fields = append(fields, fmt.Sprintf("%s: %s", field.Name, field.Type))
+		}
+	}
+	fmt.Fprintf(&sb, "\n  fields: %s\n", strings.Join(fields, ", "))
+
+	if filters := formatFilters(e); filters != "" {
+		fmt.Fprintf(&sb, "  filters (field_operator, eq is the bare field): %s\n", filters)
+	}
+
+	if len(e.OrderBy) > 0 {
+		fmt.Fprintf(&sb, "  orderBy: %s\n", strings.Join(e.OrderBy, ", "))
+	}
+
+	return sb.String()
+}
+
+// formatFilters lists the where operators of an entity, fields sharing the same operators are listed together
+func formatFilters(e thegraph.Entity) string {
+	var groups []string
+	grouped := make(map[string][]string)
+	for _, field := range e.Fields {
+		operators, found := e.Filters[field.Name]
+		if !found {
+			continue
+		}
+
+		key := strings.Join(operators, " ")
+		if _, found := grouped[key]; !found {
+			groups = append(groups, key)
+		}
+		grouped[key] = append(grouped[key], field.Name)
+	}
+
+	filters := make([]string, 0, len(groups))
+	for _, key := range groups {
+		filters = append(filters, fmt.Sprintf("%s [%s]", strings.Join(grouped[key], ", "), key))
+	}
+
+	return strings.Join(filters, "; ")
+}
+
+func formatDate(t thegraph.Timestamp) string {
+	return t.Format("2006-01-02 15:04:05 MST")
+}
+
+func formatApp(a thegraph.App) string {
+	return fmt.Sprintf("ID=%s Name=%s Owner=%s Multiaddr=%s Checksum=%s Mrenclave=%s Date=%s",
+		a.ID, a.Name, a.Owner.ID, a.Multiaddr.Text(), a.Checksum, a.Mrenclave.Text(), formatDate(a.Timestamp))
+}
+
+func formatDataset(d thegraph.Dataset) string {
+	return fmt.Sprintf("ID=%s Name=%s Owner=%s Multiaddr=%s Checksum=%s Date=%s",
+		d.ID, d.Name, d.Owner.ID, d.Multiaddr.Text(), d.Checksum, formatDate(d.Timestamp))
+}
+
+func formatWorkerpool(w thegraph.Workerpool) string {
+	return fmt.Sprintf("ID=%s Description=%s Owner=%s WorkerStakeRatio=%s%% SchedulerRewardRatio=%s%% Date=%s",
+		w.ID, w.Description, w.Owner.ID, w.WorkerStakeRatio, w.SchedulerRewardRatio, formatDate(w.Timestamp))
+}
+
+func formatAppOrder(o thegraph.AppOrder) string {
+	return fmt.Sprintf("ID=%s App=%s(%s) Price=%s Volume=%s Remaining=%s Tag=%s Restrictions=dataset:%s workerpool:%s requester:%s",
+		o.ID, o.App.Name, o.App.ID, o.AppPrice, o.Volume, o.Remaining(), o.Tag,
+		formatRestrict(o.DatasetRestrict), formatRestrict(o.WorkerpoolRestrict), formatRestrict(o.RequesterRestrict))
+}
+
+func formatDatasetOrder(o thegraph.DatasetOrder) string {
+	return fmt.Sprintf("ID=%s Dataset=%s(%s) Price=%s Volume=%s Remaining=%s Tag=%s Restrictions=app:%s workerpool:%s requester:%s",
+		o.ID, o.Dataset.Name, o.Dataset.ID, o.DatasetPrice, o.Volume, o.Remaining(), o.Tag,
+		formatRestrict(o.AppRestrict), formatRestrict(o.WorkerpoolRestrict), formatRestrict(o.RequesterRestrict))
+}
+
+func formatWorkerpoolOrder(o thegraph.WorkerpoolOrder) string {
+	return fmt.Sprintf("ID=%s Workerpool=%s(%s) Price=%s Category=%s(%s) Trust=%s Volume=%s Remaining=%s Tag=%s Restrictions=app:%s dataset:%s requester:%s",
+		o.ID, o.Workerpool.Description, o.Workerpool.ID, o.WorkerpoolPrice, o.Category.Name, o.Category.ID, o.Trust,
+		o.Volume, o.Remaining(), o.Tag,
+		formatRestrict(o.AppRestrict), formatRestrict(o.DatasetRestrict), formatRestrict(o.RequesterRestrict))
+}
+
+func formatRequestOrder(o thegraph.RequestOrder) string {
+	dataset := "none"
+	if o.Dataset != nil {
+		dataset = o.Dataset.ID
+	}
+
+	workerpool := "any"
+	if o.Workerpool != nil {
+		workerpool = o.Workerpool.ID
+	}
+
+	return fmt.Sprintf("ID=%s Requester=%s App=%s(max %s) Dataset=%s(max %s) Workerpool=%s(max %s) Category=%s Trust=%s Volume=%s Remaining=%s",
+		o.ID, o.Requester.ID, o.App.ID, o.AppMaxPrice, dataset, o.DatasetMaxPrice, workerpool, o.WorkerpoolMaxPrice,
+		o.Category.ID, o.Trust, o.Volume, o.Remaining())
+}
+
+func formatRestrict(restrict string) string {
+	if !thegraph.IsRestricted(restrict) {
+		return "none"
+	}
+
+	return restrict
+}
+
+// formatPage formats each item of the page on its own line followed by the pagination state
+func formatPage[T any](page thegraph.Page[T], format func(T) string) string {
+	var sb strings.Builder
+
+	for _, item := range page.Items {
+		sb.WriteString(format(item) + "\n")
+	}
+
+	sb.WriteString(formatNextCursor(page.NextCursor))
+
+	return sb.String()
+}
+
+// formatQueryLimits describes the queries accepted by the queryTheGraph tool, zero limits are left out
+func formatQueryLimits(limits thegraph.QueryLimits) string {
+	var bounds []string
+
+	if limits.MaxFirst > 0 {
+		bounds = append(bounds, fmt.Sprintf("first at most %d", limits.MaxFirst))
+	}
+	if limits.MaxDepth > 0 {
+		bounds = append(bounds, fmt.Sprintf("a nesting depth of %d", limits.MaxDepth))
+	}
+	if limits.MaxResults > 0 {
+		bounds = append(bounds, fmt.Sprintf("up to %d entities in the result", limits.MaxResults))
+	}
+
+	switch len(bounds) {
+	case 0:
+		return "A single query is accepted"
+	case 1:
+		return "A single query is accepted, with " + bounds[0]
+	default:
+		return "A single query is accepted, with " + strings.Join(bounds[:len(bounds)-1], ", ") + " and " + bounds[len(bounds)-1]
+	}
+}
+
+func formatNextCursor(cursor string) string {
+	if cursor == "" {
+		return "No more results"
+	}
+
+	return fmt.Sprintf("More results available, nextCursor=%s", cursor)
+}
+
+// parseVoucherFilter builds a voucher filter from the getVouchers tool arguments
+func parseVoucherFilter(request mcp.CallToolRequest) (thegraph.VoucherFilter, error) {
+	var err error
+
+	filter := thegraph.VoucherFilter{
+		IncludeZeroBalance: mcp.ParseBoolean(request, "includeZeroBalance", false),
+	}
+	filter.Owner, _ = request.GetArguments()["owner"].(string)
+	filter.VoucherType, _ = request.GetArguments()["voucherType"].(string)
+
+	if filter.ExpirationBefore, err = parseDate(request, "expirationBefore"); err != nil {
+		return filter, err
+	}
+
+	if filter.ExpirationAfter, err = parseDate(request, "expirationAfter"); err != nil {
+		return filter, err
+	}
+
+	if filter.MinBalance, err = parseAmount(request, "minBalance"); err != nil {
+		return filter, err
+	}
+
+	if filter.MaxBalance, err = parseAmount(request, "maxBalance"); err != nil {
+		return filter, err
+	}
+
+	return filter, nil
+}
+
+// parseDealFilter builds a deal filter from the getDeals tool arguments
+func parseDealFilter(request mcp.CallToolRequest) (thegraph.DealFilter, error) {
+	var err error
+
+	filter := thegraph.DealFilter{}
+	filter.Requester, _ = request.GetArguments()["requester"].(string)
+	filter.App, _ = request.GetArguments()["app"].(string)
+	filter.Dataset, _ = request.GetArguments()["dataset"].(string)
+	filter.Workerpool, _ = request.GetArguments()["workerpool"].(string)
+
+	if filter.After, err = parseDate(request, "after"); err != nil {
+		return filter, err
+	}
+
+	if filter.Before, err = parseDate(request, "before"); err != nil {
+		return filter, err
+	}
+
+	return filter, nil
+}
+
+// parseGroupBy reads the comma separated groupings of the voucherStats tool, all of them when empty
+func parseGroupBy(request mcp.CallToolRequest) ([]string, error) {
+	value, _ := request.GetArguments()["groupBy"].(string)
+	if strings.TrimSpace(value) == "" {
+		return voucherGroupings, nil
+	}
+
+	var groupBy []string
+	for _, grouping := range strings.Split(value, ",") {
+		grouping = strings.ToLower(strings.TrimSpace(grouping))
+		if !slices.Contains(voucherGroupings, grouping) {
+			return nil, errInvalidGroupBy
+		}
+		groupBy = append(groupBy, grouping)
+	}
+
+	return groupBy, nil
+}
+
+// parseDate reads an optional date argument, the zero time is returned when it is missing
+func parseDate(request mcp.CallToolRequest, key string) (time.Time, error) {
+	value, _ := request.GetArguments()[key].(string)
+	if value == "" {
+		return time.Time{}, nil
+	}
+
+	if date, err := time.Parse(time.DateOnly, value); err == nil {
+		return date, nil
+	}
+
+	date, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%s: %w", key, errInvalidDate)
+	}
+
+	return date, nil
+}
+
+// parseAmount reads an optional decimal argument, nil is returned when it is missing
+func parseAmount(request mcp.CallToolRequest, key string) (*decimal.Decimal, error) {
+	raw := mcp.ParseArgument(request, key, nil)
+	if raw == nil || raw == "" {
+		return nil, nil
+	}
+
+	amount, err := decimal.NewFromString(fmt.Sprint(raw))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", key, errInvalidAmount)
+	}
+
+	return &amount, nil
+}
+
+// templateArgument maps a template variable to a tool argument of the matching JSON type
+func templateArgument(v thegraph.TemplateVariable) mcp.ToolOption {
+	description := fmt.Sprintf("%s (GraphQL type %s)", v.Description, v.Type)
+	if !v.Required {
+		description = fmt.Sprintf("%s (GraphQL type %s, optionnal)", v.Description, v.Type)
+	}
+	description = strings.TrimSpace(description)
+
+	options := []mcp.PropertyOption{mcp.Description(description)}
+	if v.Required {
+		options = append(options, mcp.Required())
+	}
+
+	typ := strings.TrimSuffix(v.Type, "!")
+	if strings.HasPrefix(typ, "[") {
+		item := strings.TrimSuffix(strings.TrimSuffix(strings.TrimPrefix(typ, "["), "]"), "!")
+		options = append(options, mcp.Items(map[string]interface{}{"type": jsonType(item)}))
+
+		return mcp.WithArray(v.Name, options...)
+	}
+
+	switch jsonType(typ) {
+	case "number":
+		return mcp.WithNumber(v.Name, options...)
+	case "boolean":
+		return mcp.WithBoolean(v.Name, options...)
+	case "object":
+		return mcp.WithObject(v.Name, options...)
+	default:
+		return mcp.WithString(v.Name, options...)
+	}
+}
+
+// jsonType returns the JSON type of a GraphQL type, TheGraph BigInt and BigDecimal are strings. The TheGraph
+// input objects are the where filters and the block argument, the other named types are enums sent as strings
+func jsonType(graphqlType string) string {
+	switch {
+	case graphqlType == "Int" || graphqlType == "Float":
+		return "number"
+	case graphqlType == "Boolean":
+		return "boolean"
+	case strings.HasSuffix(graphqlType, "_filter") || graphqlType == "Block_height":
+		return "object"
+	default:
+		return "string"
+	}
+}
+
+// entityListArguments are the arguments of a generated list tool which are not field filters
+var entityListArguments = []string{"where", "orderBy", "orderDirection", "limit", "skip", "block"}
+
+// equalityFilters returns the fields of an entity exposed as equality filter arguments of its list tool,
+// lists and fields named after another argument are only reachable through the where filter
+func equalityFilters(e thegraph.Entity) []thegraph.EntityField {
+	var fields []thegraph.EntityField
+
+	for _, field := range e.Fields {
+		if strings.HasPrefix(field.Type, "[") || slices.Contains(entityListArguments, field.Name) ||
+			!slices.Contains(e.Filters[field.Name], "eq") {
+			continue
+		}
+
+		fields = append(fields, field)
+	}
+
+	return fields
+}
+
+// entityArguments derives the arguments of the list tool of an entity from its where filter,
+// its orderBy values and the pagination
+func entityArguments(e thegraph.Entity) []mcp.ToolOption {
+	var options []mcp.ToolOption
+
+	for _, field := range equalityFilters(e) {
+		description := mcp.Description(fmt.Sprintf("Only %s entities whose %s equals this value (GraphQL type %s, optionnal)", e.Name, field.Name, field.Type))
+
+		// Related entities are filtered by ID
+		typ := "string"
+		if field.Relation == "" {
+			typ = jsonType(strings.TrimSuffix(field.Type, "!"))
+		}
+
+		switch typ {
+		case "number":
+			options = append(options, mcp.WithNumber(field.Name, description))
+		case "boolean":
+			options = append(options, mcp.WithBoolean(field.Name, description))
+		default:
+			options = append(options, mcp.WithString(field.Name, description))
+		}
+	}
+
+	options = append(options,
+		mcp.WithObject("where",
+			mcp.Description("TheGraph where filter combined with the field arguments, e.g. {\"field_gt\": \"0\"}, operators by field: "+
+				formatFilters(e)+" (optionnal)"),
+		),
+	)
+
+	if len(e.OrderBy) > 0 {
+		options = append(options,
+			mcp.WithString("orderBy",
+				mcp.Description("Field to order the entities by (optionnal, default id)"),
+				mcp.Enum(e.OrderBy...),
+			),
+		)
+	}
+
+	return append(options,
+		mcp.WithString("orderDirection",
+			mcp.Description("Order direction (optionnal, default asc)"),
+			mcp.Enum("asc", "desc"),
+		),
+		mcp.WithNumber("limit",
+			mcp.Description("Maximum number of entities (optionnal, default 100, max 1000)"),
+		),
+		mcp.WithNumber("skip",
+			mcp.Description("Number of entities to skip for the next pages (optionnal, default 0)"),
+		),
+		withBlockArgument(),
+	)
+}
+
+// entityToolName names a generated tool after the root field it queries, e.g. listDeals,
+// the subgraph name is added when the name is already taken, e.g. listPocoDeals
+func entityToolName(taken []string, verb, subgraph, field string) string {
+	name := verb + capitalize(field)
+	if slices.Contains(taken, name) {
+		name = verb + capitalize(subgraph) + capitalize(field)
+	}
+
+	return name
+}
+
+func capitalize(s string) string {
+	if s == "" {
+		return s
+	}
+
+	return strings.ToUpper(s[:1]) + s[1:]
+}
+
+// withBlockArgument is the block argument of the tools reading the subgraphs, it is read by pinBlock
+func withBlockArgument() mcp.ToolOption {
+	return mcp.WithString("block",
+		mcp.Description("Read the data as of this block number or block hash (optionnal, default latest indexed block)"),
+	)
+}
+
+// pinBlock returns the client pinned to the block argument, if any. The block number may be sent as a JSON number
+func pinBlock(request mcp.CallToolRequest, client *thegraph.Client) (*thegraph.Client, error) {
+	var value string
+
+	switch v := request.GetArguments()["block"].(type) {
+	case nil:
+	case string:
+		value = v
+	case json.Number:
+		value = v.String()
+	case float64:
+		if v < 1 || v != math.Trunc(v) || v > 1<<53 {
+			return nil, errInvalidBlock
+		}
+		value = strconv.FormatUint(uint64(v), 10)
+	default:
+		return nil, errInvalidBlock
+	}
+
+	block, err := thegraph.ParseBlock(value)
+	if err != nil {
+		return nil, err
+	}
+
+	if block.IsLatest() {
+		return client, nil
+	}
+
+	return client.AtBlock(block), nil
+}
+
+// parseVariables accepts GraphQL variables either as an object or as a JSON encoded string
+func parseVariables(raw interface{}) (map[string]interface{}, error) {
+	switch v := raw.(type) {
+	case nil:
+		return nil, nil
+	case map[string]interface{}:
+		return v, nil
+	case string:
+		if v == "" {
+			return nil, nil
+		}
+
+		var variables map[string]interface{}
+		if err := json.Unmarshal([]byte(v), &variables); err != nil {
+			return nil, errInvalidVariables
+		}
+
+		return variables, nil
+	default:
+		return nil, errInvalidVariables
+	}
 }
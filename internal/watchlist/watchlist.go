@@ -0,0 +1,347 @@
+// Package watchlist watches iExec vouchers and raises alerts when they are about to expire, are fully
+// consumed or their balance drops
+package watchlist
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/thewhitewizard/thegraph-mcp-server/pkg/thegraph"
+)
+
+// Kinds of voucher alerts
+const (
+	AlertExpiring    = "expiring"
+	AlertConsumed    = "consumed"
+	AlertBalanceDrop = "balanceDrop"
+)
+
+const defaultWatchInterval = 5 * time.Minute
+
+var errInvalidWatchlist = errors.New("invalid voucher watchlist")
+
+// Rules tell when a watched voucher raises an alert, a zero rule is disabled
+type Rules struct {
+	// ExpiryWithin alerts once when a voucher expires within this duration
+	ExpiryWithin time.Duration
+	// BalanceDrop alerts when the balance decreased by more than this amount since the last alert
+	BalanceDrop decimal.Decimal
+}
+
+// Alert is raised once per event of a watched voucher
+type Alert struct {
+	Kind    string           `json:"kind"`
+	Message string           `json:"message"`
+	Voucher thegraph.Voucher `json:"voucher"`
+}
+
+// Watchlist watches the vouchers of owners and single vouchers, its state is saved to a JSON file
+// so that an alert is raised once across restarts
+type Watchlist struct {
+	mu    sync.Mutex
+	path  string
+	rules Rules
+	state watchState
+	now   func() time.Time
+}
+
+// watchState is the content of the watchlist file
+type watchState struct {
+	Owners   []string                  `json:"owners"`
+	Vouchers []string                  `json:"vouchers"`
+	Seen     map[string]watchedVoucher `json:"seen"`
+}
+
+// watchedVoucher is what was last reported of a voucher
+type watchedVoucher struct {
+	Owner      string             `json:"owner,omitempty"`
+	Balance    decimal.Decimal    `json:"balance"`
+	Expiration thegraph.Timestamp `json:"expiration"`
+	Expiring   bool               `json:"expiring,omitempty"`
+	Consumed   bool               `json:"consumed,omitempty"`
+}
+
+// New creates a watchlist saved to path, the previous state is loaded when the file exists.
+// An empty path keeps the state in memory
+func New(path string, rules Rules) (*Watchlist, error) {
+	w := &Watchlist{
+		path:  path,
+		rules: rules,
+		state: watchState{Seen: make(map[string]watchedVoucher)},
+		now:   time.Now,
+	}
+
+	if path == "" {
+		return w, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return w, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", errInvalidWatchlist, err)
+	}
+
+	if err := json.Unmarshal(data, &w.state); err != nil {
+		return nil, fmt.Errorf("%w %s: %v", errInvalidWatchlist, path, err)
+	}
+
+	if w.state.Seen == nil {
+		w.state.Seen = make(map[string]watchedVoucher)
+	}
+
+	return w, nil
+}
+
+// WatchOwner watches all the vouchers of an owner
+func (w *Watchlist) WatchOwner(owner string) error {
+	if strings.TrimSpace(owner) == "" {
+		return fmt.Errorf("%w: empty owner", errInvalidWatchlist)
+	}
+
+	return w.update(func(state *watchState) {
+		state.Owners = addAddress(state.Owners, owner)
+	})
+}
+
+// WatchVoucher watches a single voucher
+func (w *Watchlist) WatchVoucher(id string) error {
+	if strings.TrimSpace(id) == "" {
+		return fmt.Errorf("%w: empty voucher ID", errInvalidWatchlist)
+	}
+
+	return w.update(func(state *watchState) {
+		state.Vouchers = addAddress(state.Vouchers, id)
+	})
+}
+
+// Unwatch stops watching an owner or a voucher and forgets what was reported of the vouchers which
+// are no longer watched, so that watching them again raises their alerts again
+func (w *Watchlist) Unwatch(address string) error {
+	address = strings.ToLower(strings.TrimSpace(address))
+
+	return w.update(func(state *watchState) {
+		state.Owners = slices.DeleteFunc(state.Owners, func(owner string) bool { return owner == address })
+		state.Vouchers = slices.DeleteFunc(state.Vouchers, func(id string) bool { return id == address })
+
+		for id, seen := range state.Seen {
+			if (id == address || seen.Owner == address) &&
+				!slices.Contains(state.Vouchers, id) && !slices.Contains(state.Owners, seen.Owner) {
+				delete(state.Seen, id)
+			}
+		}
+	})
+}
+
+// Watched returns the watched owners and vouchers
+func (w *Watchlist) Watched() (owners, vouchers []string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return slices.Clone(w.state.Owners), slices.Clone(w.state.Vouchers)
+}
+
+// Rules returns the rules of the watchlist
+func (w *Watchlist) Rules() Rules {
+	return w.rules
+}
+
+// Check polls the watched vouchers once and passes the new alerts to notify. What is reported of the vouchers
+// is only saved once notify succeeds, so that undelivered alerts are raised again by the next check. Vouchers
+// which can not be fetched are skipped and reported by the error
+func (w *Watchlist) Check(ctx context.Context, c *thegraph.Client, notify func([]Alert) error) error {
+	owners, ids := w.Watched()
+	now := w.now()
+
+	var vouchers []thegraph.Voucher
+	var errs []error
+
+	for _, owner := range owners {
+		// Expired vouchers can no longer be used, they are not watched
+		response, err := c.GetVouchers(ctx, thegraph.VoucherFilter{Owner: owner, ExpirationAfter: now, IncludeZeroBalance: true})
+		if err != nil {
+			errs = append(errs, fmt.Errorf("owner %s: %w", owner, err))
+			continue
+		}
+		vouchers = append(vouchers, response.Data.Vouchers...)
+	}
+
+	for _, id := range ids {
+		voucher, err := c.GetVoucher(ctx, id)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("voucher %s: %w", id, err))
+			continue
+		}
+
+		if voucher.Expiration.After(now) && !slices.ContainsFunc(vouchers, func(v thegraph.Voucher) bool { return v.ID == voucher.ID }) {
+			vouchers = append(vouchers, voucher.Voucher)
+		}
+	}
+
+	var alerts []Alert
+	reported := make(map[string]watchedVoucher, len(vouchers))
+
+	w.mu.Lock()
+	for _, voucher := range vouchers {
+		seen, found := w.state.Seen[voucher.ID]
+		seen, voucherAlerts := w.evaluate(seen, found, voucher, now)
+		reported[voucher.ID] = seen
+		alerts = append(alerts, voucherAlerts...)
+	}
+	w.mu.Unlock()
+
+	if len(alerts) > 0 {
+		if err := notify(alerts); err != nil {
+			errs = append(errs, fmt.Errorf("alerts not delivered: %w", err))
+			return errors.Join(errs...)
+		}
+	}
+
+	err := w.update(func(state *watchState) {
+		for id, seen := range reported {
+			// The vouchers unwatched during the notification stay forgotten
+			if slices.Contains(state.Vouchers, id) || slices.Contains(state.Owners, seen.Owner) {
+				state.Seen[id] = seen
+			}
+		}
+
+		// Expired vouchers will not raise alerts anymore
+		for id, seen := range state.Seen {
+			if seen.Expiration.Before(now) {
+				delete(state.Seen, id)
+			}
+		}
+	})
+	errs = append(errs, err)
+
+	return errors.Join(errs...)
+}
+
+// Run checks the watchlist every interval, 5 minutes when zero, until ctx is done. The alerts are passed
+// to notify and the errors to onError. The first check waits for an interval so that clients can connect
+func (w *Watchlist) Run(ctx context.Context, c *thegraph.Client, interval time.Duration, notify func([]Alert) error, onError func(error)) {
+	if interval <= 0 {
+		interval = defaultWatchInterval
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if err := w.Check(ctx, c, notify); err != nil {
+			onError(err)
+		}
+	}
+}
+
+// evaluate compares a voucher with what was last reported of it, found tells whether it was reported before.
+// It returns what to report of the voucher and its new alerts
+func (w *Watchlist) evaluate(seen watchedVoucher, found bool, v thegraph.Voucher, now time.Time) (watchedVoucher, []Alert) {
+	var alerts []Alert
+
+	if !found {
+		seen.Balance = v.Balance.Decimal
+	}
+	seen.Owner = strings.ToLower(v.Owner.ID)
+	seen.Expiration = v.Expiration
+
+	consumed := !v.Balance.IsPositive()
+
+	switch {
+	case consumed && !seen.Consumed:
+		alerts = append(alerts, Alert{
+			Kind:    AlertConsumed,
+			Message: fmt.Sprintf("voucher %s of %s is fully consumed", v.ID, v.Owner.ID),
+			Voucher: v,
+		})
+	case w.rules.BalanceDrop.IsPositive() && seen.Balance.Sub(v.Balance.Decimal).GreaterThan(w.rules.BalanceDrop):
+		alerts = append(alerts, Alert{
+			Kind:    AlertBalanceDrop,
+			Message: fmt.Sprintf("voucher %s of %s balance dropped from %s to %s", v.ID, v.Owner.ID, seen.Balance, v.Balance),
+			Voucher: v,
+		})
+	}
+
+	// The balance of the last alert is the reference of the next drop, a refill raises it
+	if consumed || len(alerts) > 0 || v.Balance.GreaterThan(seen.Balance) {
+		seen.Balance = v.Balance.Decimal
+	}
+	seen.Consumed = consumed
+
+	if w.rules.ExpiryWithin > 0 && !seen.Expiring && !consumed && v.Expiration.Sub(now) <= w.rules.ExpiryWithin {
+		seen.Expiring = true
+		alerts = append(alerts, Alert{
+			Kind: AlertExpiring,
+			Message: fmt.Sprintf("voucher %s of %s expires on %s with a balance of %s",
+				v.ID, v.Owner.ID, v.Expiration.UTC().Format(time.RFC3339), v.Balance),
+			Voucher: v,
+		})
+	}
+
+	return seen, alerts
+}
+
+// update changes the state and saves it
+func (w *Watchlist) update(change func(state *watchState)) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	change(&w.state)
+
+	if w.path == "" {
+		return nil
+	}
+
+	data, err := json.MarshalIndent(w.state, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	// Written to a temporary file first so that a crash does not leave a truncated state
+	tmp, err := os.CreateTemp(filepath.Dir(w.path), filepath.Base(w.path)+".*")
+	if err != nil {
+		return fmt.Errorf("%w: %v", errInvalidWatchlist, err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("%w: %v", errInvalidWatchlist, err)
+	}
+
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("%w: %v", errInvalidWatchlist, err)
+	}
+
+	if err := os.Rename(tmp.Name(), w.path); err != nil {
+		return fmt.Errorf("%w: %v", errInvalidWatchlist, err)
+	}
+
+	return nil
+}
+
+// addAddress adds a lower cased address to a list unless it is already there
+func addAddress(addresses []string, address string) []string {
+	address = strings.ToLower(strings.TrimSpace(address))
+	if slices.Contains(addresses, address) {
+		return addresses
+	}
+
+	return append(addresses, address)
+}
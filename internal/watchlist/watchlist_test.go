@@ -0,0 +1,175 @@
+package watchlist
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/thewhitewizard/thegraph-mcp-server/pkg/thegraph"
+)
+
+// roundTripFunc answers the requests of a mocked TheGraph client
+type roundTripFunc func(req *http.Request) (*http.Response, error)
+
+func (fn roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return fn(req)
+}
+
+func newMockedClient(fn roundTripFunc) *thegraph.Client {
+	return thegraph.NewClient("http://mocked", thegraph.WithTransport(fn), thegraph.WithoutRetry(), thegraph.WithoutCircuitBreaker())
+}
+
+func newResponse(body string) *http.Response {
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Body:       io.NopCloser(bytes.NewBufferString(body)),
+		Header:     make(http.Header),
+	}
+}
+
+func TestWatchlistPersistence(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "watchlist.json")
+
+	w, err := New(path, Rules{})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	for _, watch := range []func() error{
+		func() error { return w.WatchOwner("0xA") },
+		func() error { return w.WatchOwner("0xa") },
+		func() error { return w.WatchOwner("0xB") },
+		func() error { return w.WatchVoucher("0xC") },
+		func() error { return w.Unwatch("0xB") },
+	} {
+		if err := watch(); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+	}
+
+	if err := w.WatchOwner(" "); !errors.Is(err, errInvalidWatchlist) {
+		t.Errorf("expected an empty owner to be rejected, got %v", err)
+	}
+
+	reloaded, err := New(path, Rules{})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	owners, vouchers := reloaded.Watched()
+	if !slices.Equal(owners, []string{"0xa"}) || !slices.Equal(vouchers, []string{"0xc"}) {
+		t.Errorf("unexpected watchlist %v %v", owners, vouchers)
+	}
+}
+
+func TestWatchlistCheck(t *testing.T) {
+	now := time.Unix(1750000000, 0)
+	balances := map[string]string{"0x1": "10", "0x2": "0", "0x3": "8"}
+	expirations := map[string]time.Time{"0x1": now.Add(48 * time.Hour), "0x2": now.Add(30 * 24 * time.Hour), "0x3": now.Add(30 * 24 * time.Hour)}
+
+	voucherJSON := func(id string) string {
+		return fmt.Sprintf(`{"id": %q, "owner": {"id": "0xa"}, "expiration": "%d", "value": "10", "balance": %q}`,
+			id, expirations[id].Unix(), balances[id])
+	}
+
+	client := newMockedClient(func(req *http.Request) (*http.Response, error) {
+		var payload struct {
+			Query string `json:"query"`
+		}
+		if err := json.NewDecoder(req.Body).Decode(&payload); err != nil {
+			t.Fatalf("failed to decode request body: %v", err)
+		}
+
+		if strings.Contains(payload.Query, "query voucher(") {
+			return newResponse(`{"data": {"voucher": ` + voucherJSON("0x3") + `}}`), nil
+		}
+
+		return newResponse(`{"data": {"vouchers": [` + voucherJSON("0x1") + `, ` + voucherJSON("0x2") + `]}}`), nil
+	})
+
+	path := filepath.Join(t.TempDir(), "watchlist.json")
+	rules := Rules{ExpiryWithin: 7 * 24 * time.Hour, BalanceDrop: decimal.NewFromInt(5)}
+
+	newWatchlist := func() *Watchlist {
+		w, err := New(path, rules)
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		w.now = func() time.Time { return now }
+
+		return w
+	}
+
+	w := newWatchlist()
+	if err := w.WatchOwner("0xa"); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.WatchVoucher("0x3"); err != nil {
+		t.Fatal(err)
+	}
+
+	check := func(w *Watchlist, expected ...string) {
+		t.Helper()
+
+		var alerts []Alert
+		err := w.Check(context.Background(), client, func(a []Alert) error {
+			alerts = a
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		var kinds []string
+		for _, alert := range alerts {
+			kinds = append(kinds, alert.Voucher.ID+" "+alert.Kind)
+		}
+
+		if !slices.Equal(kinds, expected) {
+			t.Errorf("expected alerts %v, got %v", expected, kinds)
+		}
+	}
+
+	// Undelivered alerts are raised again
+	errDelivery := errors.New("webhook down")
+	if err := w.Check(context.Background(), client, func([]Alert) error { return errDelivery }); !errors.Is(err, errDelivery) {
+		t.Fatalf("expected the delivery error, got %v", err)
+	}
+
+	check(w, "0x1 expiring", "0x2 consumed")
+	check(w)
+
+	// Drops are measured from the balance of the last alert
+	balances["0x3"] = "4"
+	check(w)
+	balances["0x3"] = "2.5"
+	check(w, "0x3 balanceDrop")
+	check(w)
+
+	// A restart does not raise the same alerts again
+	check(newWatchlist())
+
+	balances["0x2"] = "3"
+	check(w)
+	balances["0x2"] = "0"
+	check(w, "0x2 consumed")
+
+	// Watching an owner again raises the alerts of its vouchers again, except for the vouchers watched on their own
+	if err := w.Unwatch("0xA"); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.WatchOwner("0xa"); err != nil {
+		t.Fatal(err)
+	}
+	check(w, "0x1 expiring", "0x2 consumed")
+}
@@ -7,18 +7,23 @@ import (
 	"log"
 	"os"
 	"os/signal"
+	"strconv"
+	"strings"
 	"syscall"
 	"time"
 
 	"github.com/joho/godotenv"
 	"github.com/mark3labs/mcp-go/server"
+	"github.com/shopspring/decimal"
 	"github.com/thewhitewizard/thegraph-mcp-server/internal/mcp"
+	"github.com/thewhitewizard/thegraph-mcp-server/internal/watchlist"
 	"github.com/thewhitewizard/thegraph-mcp-server/pkg/chain"
 	"github.com/thewhitewizard/thegraph-mcp-server/pkg/thegraph"
 )
 
 const (
-	cancelTimeOut = 5 * time.Second
+	cancelTimeOut        = 5 * time.Second
+	introspectionTimeOut = 30 * time.Second
 )
 
 func main() {
@@ -26,6 +31,25 @@ func main() {
 	useSSE := flag.Bool("sse", false, "Use SSE server mode (default is stdin/stdout)")
 	port := flag.String("port", "", "Port for SSE server (defaults to PORT env var or 4000)")
 	theGraphURL := flag.String("thegraph-url", "", "TheGraph URL, default "+thegraph.DEFAULT_URL)
+	theGraphAPIKey := flag.String("thegraph-api-key", "", "API key sent as a bearer token to TheGraph (defaults to THEGRAPH_API_KEY env var)")
+	theGraphUserAgent := flag.String("thegraph-user-agent", "", "User-Agent sent to TheGraph (defaults to THEGRAPH_USER_AGENT env var)")
+	theGraphTimeout := flag.Duration("thegraph-timeout", 0, "Timeout of TheGraph requests (defaults to THEGRAPH_TIMEOUT env var or 10s)")
+	theGraphCacheSize := flag.Int("thegraph-cache-size", 0, "Number of TheGraph responses kept in cache, the cache is disabled unless set (defaults to THEGRAPH_CACHE_SIZE env var)")
+	theGraphCacheTTL := flag.Duration("thegraph-cache-ttl", 0, "How long TheGraph responses are cached (defaults to THEGRAPH_CACHE_TTL env var or 30s)")
+	theGraphCacheBlockAware := flag.Bool("thegraph-cache-block-aware", false, "Drop the cached responses of a subgraph when it indexes a new block (defaults to THEGRAPH_CACHE_BLOCK_AWARE env var)")
+	theGraphConfig := flag.String("thegraph-config", "", "JSON file declaring named subgraphs with their URL, headers and timeout (defaults to THEGRAPH_CONFIG env var)")
+	var theGraphSubgraphs []string
+	flag.Func("thegraph-subgraph", "Named subgraph as name=url, can be repeated (defaults to THEGRAPH_SUBGRAPHS env var, comma separated)", func(value string) error {
+		theGraphSubgraphs = append(theGraphSubgraphs, value)
+		return nil
+	})
+	templatesDir := flag.String("templates", "", "Directory of .graphql query templates registered as tools (defaults to TEMPLATES_DIR env var)")
+	generateTools := flag.String("generate-tools", "", "Comma separated subgraph names whose entities get generated list and get tools (defaults to GENERATE_TOOLS env var)")
+	watchlistFile := flag.String("watchlist-file", "", "JSON file keeping the voucher watchlist and its alerts, enables the watchlist (defaults to WATCHLIST_FILE env var)")
+	watchlistInterval := flag.Duration("watchlist-interval", 0, "How often the watched vouchers are polled (defaults to WATCHLIST_INTERVAL env var or 5m)")
+	watchlistExpiryDays := flag.Int("watchlist-expiry-days", -1, "Alert when a watched voucher expires within this number of days, 0 disables the expiry alerts (defaults to WATCHLIST_EXPIRY_DAYS env var or 7)")
+	watchlistBalanceDrop := flag.String("watchlist-balance-drop", "", "Alert when the balance of a watched voucher drops by more than this amount of RLC, empty disables (defaults to WATCHLIST_BALANCE_DROP env var)")
+	watchlistWebhook := flag.String("watchlist-webhook", "", "URL receiving the voucher alerts as a JSON POST (defaults to WATCHLIST_WEBHOOK env var)")
 	chainRPC := flag.String("rpc", "", "RPC for chain interaction, default "+chain.DEFAULT_URL)
 
 	flag.Parse()
@@ -52,6 +76,96 @@ func main() {
 		*theGraphURL = getEnv("THEGRAPH_URL", thegraph.DEFAULT_URL)
 	}
 
+	// If TheGraph API key or User-Agent flags not set, get from env
+	if *theGraphAPIKey == "" {
+		*theGraphAPIKey = getEnv("THEGRAPH_API_KEY", "")
+	}
+
+	if *theGraphUserAgent == "" {
+		*theGraphUserAgent = getEnv("THEGRAPH_USER_AGENT", "")
+	}
+
+	// If theGraphTimeout flag not set, get from env
+	if *theGraphTimeout == 0 {
+		if timeout, err := time.ParseDuration(getEnv("THEGRAPH_TIMEOUT", "0s")); err == nil {
+			*theGraphTimeout = timeout
+		} else {
+			log.Printf("Warning: invalid THEGRAPH_TIMEOUT: %v", err)
+		}
+	}
+
+	// If TheGraph cache flags not set, get from env
+	if *theGraphCacheSize == 0 {
+		if size, err := strconv.Atoi(getEnv("THEGRAPH_CACHE_SIZE", "0")); err == nil {
+			*theGraphCacheSize = size
+		} else {
+			log.Printf("Warning: invalid THEGRAPH_CACHE_SIZE: %v", err)
+		}
+	}
+
+	if *theGraphCacheTTL == 0 {
+		if ttl, err := time.ParseDuration(getEnv("THEGRAPH_CACHE_TTL", "0s")); err == nil {
+			*theGraphCacheTTL = ttl
+		} else {
+			log.Printf("Warning: invalid THEGRAPH_CACHE_TTL: %v", err)
+		}
+	}
+
+	if getEnv("THEGRAPH_CACHE_BLOCK_AWARE", "") == "true" {
+		*theGraphCacheBlockAware = true
+	}
+
+	// If TheGraph config or subgraphs flags not set, get from env
+	if *theGraphConfig == "" {
+		*theGraphConfig = getEnv("THEGRAPH_CONFIG", "")
+	}
+
+	if len(theGraphSubgraphs) == 0 {
+		if subgraphs := getEnv("THEGRAPH_SUBGRAPHS", ""); subgraphs != "" {
+			theGraphSubgraphs = strings.Split(subgraphs, ",")
+		}
+	}
+
+	// If templates flag not set, get from env
+	if *templatesDir == "" {
+		*templatesDir = getEnv("TEMPLATES_DIR", "")
+	}
+
+	// If generate tools flag not set, get from env
+	if *generateTools == "" {
+		*generateTools = getEnv("GENERATE_TOOLS", "")
+	}
+
+	// If watchlist flags not set, get from env or use default
+	if *watchlistFile == "" {
+		*watchlistFile = getEnv("WATCHLIST_FILE", "")
+	}
+
+	if *watchlistInterval == 0 {
+		if interval, err := time.ParseDuration(getEnv("WATCHLIST_INTERVAL", "5m")); err == nil {
+			*watchlistInterval = interval
+		} else {
+			log.Printf("Warning: invalid WATCHLIST_INTERVAL: %v", err)
+		}
+	}
+
+	// 0 disables the expiry alerts, the flag is unset when negative
+	if *watchlistExpiryDays < 0 {
+		if days, err := strconv.Atoi(getEnv("WATCHLIST_EXPIRY_DAYS", "7")); err == nil {
+			*watchlistExpiryDays = days
+		} else {
+			log.Printf("Warning: invalid WATCHLIST_EXPIRY_DAYS: %v", err)
+		}
+	}
+
+	if *watchlistBalanceDrop == "" {
+		*watchlistBalanceDrop = getEnv("WATCHLIST_BALANCE_DROP", "")
+	}
+
+	if *watchlistWebhook == "" {
+		*watchlistWebhook = getEnv("WATCHLIST_WEBHOOK", "")
+	}
+
 	// If port flag not set, get from env or use default
 	if *port == "" {
 		*port = getEnv("PORT", "4000")
@@ -68,37 +182,152 @@ func main() {
 	}
 
 	// Initialize clients
-	thegraphCient := thegraph.NewClient(*theGraphURL)
+	var thegraphOptions []thegraph.Option
+	if *theGraphAPIKey != "" {
+		thegraphOptions = append(thegraphOptions, thegraph.WithAPIKey(*theGraphAPIKey))
+	}
+
+	if *theGraphUserAgent != "" {
+		thegraphOptions = append(thegraphOptions, thegraph.WithUserAgent(*theGraphUserAgent))
+	}
+
+	if *theGraphTimeout > 0 {
+		thegraphOptions = append(thegraphOptions, thegraph.WithTimeout(*theGraphTimeout))
+	}
+
+	if *theGraphCacheSize > 0 {
+		cache := thegraph.NewCache(*theGraphCacheSize, *theGraphCacheTTL)
+		if *theGraphCacheBlockAware {
+			cache.InvalidateOnNewBlock()
+		}
+
+		thegraphOptions = append(thegraphOptions, thegraph.WithCache(cache))
+	}
+
+	// Subgraphs from the config file, then from flags or env, the last declaration of a name wins
+	if *theGraphConfig != "" {
+		subgraphs, err := thegraph.LoadSubgraphs(*theGraphConfig)
+		if err != nil {
+			log.Fatalf("Error loading TheGraph config: %v", err)
+		}
+
+		for _, subgraph := range subgraphs {
+			thegraphOptions = append(thegraphOptions, thegraph.WithSubgraph(subgraph))
+		}
+	}
+
+	for _, value := range theGraphSubgraphs {
+		subgraph, err := thegraph.ParseSubgraph(value)
+		if err != nil {
+			log.Fatalf("Error parsing TheGraph subgraph: %v", err)
+		}
+
+		thegraphOptions = append(thegraphOptions, thegraph.WithSubgraph(subgraph))
+	}
+
+	thegraphCient := thegraph.NewClient(*theGraphURL, thegraphOptions...)
 	chainClient := chain.NewClient(*chainRPC)
 
+	// Set up signal handling for graceful shutdown of the watchlist and of the server
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// The watchlist sends its alerts as logging notifications
+	var serverOptions []server.ServerOption
+	var logs *mcp.LogNotifier
+	if *watchlistFile != "" {
+		logs = mcp.NewLogNotifier()
+		serverOptions = append(serverOptions, server.WithLogging(), server.WithHooks(logs.Hooks()))
+	}
+
 	// Create MCP server
 	mcpServer := server.NewMCPServer(
 		"TheGraph MCP Server",
 		"1.0.0",
+		serverOptions...,
 	)
+	if logs != nil {
+		logs.SetServer(mcpServer)
+	}
+
+	// Names of the registered tools, the query templates can not replace them
+	var toolNames []string
+
+	// Generated tools are registered first so that the hand-written tools of the same name replace them
+	if *generateTools != "" {
+		schemas := make(map[string]thegraph.Schema)
+
+		for _, subgraph := range strings.Split(*generateTools, ",") {
+			subgraph = strings.TrimSpace(subgraph)
+
+			ctx, cancel := context.WithTimeout(context.Background(), introspectionTimeOut)
+			schema, err := thegraphCient.GetSchema(ctx, subgraph)
+			cancel()
+			if err != nil {
+				log.Printf("Warning: no tools generated for subgraph %s: %v", subgraph, err)
+				continue
+			}
+
+			schemas[subgraph] = schema
+		}
+
+		names := mcp.RegisterEntityTools(mcpServer, thegraphCient, schemas)
+		log.Printf("Generated %d tools from %d subgraph schemas", len(names), len(schemas))
+		toolNames = append(toolNames, names...)
+	}
 
 	// Register tools
-	mcp.RegisterTools(mcpServer, thegraphCient, chainClient)
+	toolNames = append(toolNames, mcp.RegisterTools(mcpServer, thegraphCient, chainClient)...)
+
+	if *watchlistFile != "" {
+		rules := watchlist.Rules{ExpiryWithin: time.Duration(*watchlistExpiryDays) * 24 * time.Hour}
+
+		if *watchlistBalanceDrop != "" {
+			drop, err := decimal.NewFromString(*watchlistBalanceDrop)
+			if err != nil {
+				log.Fatalf("Error parsing watchlist balance drop: %v", err)
+			}
+			rules.BalanceDrop = drop
+		}
+
+		voucherWatchlist, err := watchlist.New(*watchlistFile, rules)
+		if err != nil {
+			log.Fatalf("Error loading voucher watchlist: %v", err)
+		}
+
+		toolNames = append(toolNames, mcp.RegisterWatchlistTools(mcpServer, voucherWatchlist)...)
+
+		go voucherWatchlist.Run(ctx, thegraphCient, *watchlistInterval, mcp.AlertNotifier(logs, *watchlistWebhook), func(err error) {
+			log.Printf("Error checking voucher watchlist: %v", err)
+		})
+		log.Printf("Watching vouchers every %s", *watchlistInterval)
+	}
+
+	if *templatesDir != "" {
+		templates, err := thegraph.LoadTemplates(*templatesDir, toolNames)
+		if err != nil {
+			log.Fatalf("Error loading query templates: %v", err)
+		}
+
+		mcp.RegisterTemplates(mcpServer, thegraphCient, templates)
+		log.Printf("Registered %d query templates from %s", len(templates), *templatesDir)
+	}
 
 	if *useSSE {
 		// SSE server mode
 		log.Printf("Starting in SSE mode...")
-		runSSEServer(mcpServer, *port)
+		runSSEServer(ctx, mcpServer, *port)
 	} else {
 		// Default StdIO server mode
 		log.Printf("Starting in StdIO mode...")
-		runStdIOServer(mcpServer)
+		runStdIOServer(ctx, mcpServer)
 	}
 }
 
-func runSSEServer(mcpServer *server.MCPServer, port string) {
+func runSSEServer(ctx context.Context, mcpServer *server.MCPServer, port string) {
 	// Create custom SSE server
 	sseServer := mcp.NewCustomSSEServer(mcpServer)
 
-	// Set up signal handling for graceful shutdown
-	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
-	defer stop()
-
 	// Start the server in a goroutine
 	go func() {
 		addr := fmt.Sprintf(":%s", port)
@@ -111,7 +340,6 @@ func runSSEServer(mcpServer *server.MCPServer, port string) {
 
 	// Wait for interruption signal
 	<-ctx.Done()
-	stop()
 	log.Println("Shutting down server...")
 
 	// Create a timeout context for shutdown
@@ -126,7 +354,7 @@ func runSSEServer(mcpServer *server.MCPServer, port string) {
 	log.Println("Server gracefully stopped")
 }
 
-func runStdIOServer(mcpServer *server.MCPServer) {
+func runStdIOServer(ctx context.Context, mcpServer *server.MCPServer) {
 	// Create custom StdIO server
 	stdioServer := mcp.NewCustomStdioServer(mcpServer)
 
@@ -137,7 +365,7 @@ func runStdIOServer(mcpServer *server.MCPServer) {
 	log.Printf("Starting TheGraph MCP Server in StdIO mode")
 
 	// Start listening on stdin/stdout
-	if err := stdioServer.Start(); err != nil {
+	if err := stdioServer.Start(ctx); err != nil {
 		log.Fatalf("Server error: %v", err)
 	}
 }
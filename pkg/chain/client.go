@@ -65,6 +65,16 @@ func (c *Client) CurrentBlock(ctx context.Context) (uint64, error) {
 	return c.conn.BlockNumber(ctx)
 }
 
+// CurrentBlockTime return the timestamp of the current block
+func (c *Client) CurrentBlockTime(ctx context.Context) (time.Time, error) {
+	header, err := c.conn.HeaderByNumber(ctx, nil)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	return time.Unix(int64(header.Time), 0), nil
+}
+
 // GetBalance return the wallet balance
 func (c *Client) GetBalance(ctx context.Context, wallet string, decimals int) string {
 	if c.down {
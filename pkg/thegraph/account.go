@@ -0,0 +1,138 @@
+package thegraph
+
+import (
+	"context"
+	"slices"
+	"strings"
+)
+
+const accountActivityQuery = `
+	query accountActivity($id: ID!, $account: String!, $first: Int!) {
+		account(id: $id) {
+			id
+			balance
+			frozen
+		}
+		requestedDeals: deals(orderBy: timestamp, orderDirection: desc, first: $first, where: {requester: $account}) {
+			...dealSummary
+		}
+		scheduledDeals: deals(orderBy: timestamp, orderDirection: desc, first: $first, where: {workerpoolOwner: $account}) {
+			...dealSummary
+		}
+		contributions(orderBy: timestamp, orderDirection: desc, first: $first, where: {worker: $account}) {
+			id
+			status
+			timestamp
+			task {
+				id
+				status
+				deal {
+					id
+				}
+			}
+		}
+		apps(orderBy: timestamp, orderDirection: desc, first: $first, where: {owner: $account}) {
+			id
+			name
+			timestamp
+		}
+		datasets(orderBy: timestamp, orderDirection: desc, first: $first, where: {owner: $account}) {
+			id
+			name
+			timestamp
+		}
+		workerpools(orderBy: timestamp, orderDirection: desc, first: $first, where: {owner: $account}) {
+			id
+			description
+			timestamp
+		}
+		sent: transfers(orderBy: timestamp, orderDirection: desc, first: $first, where: {from: $account}) {
+			...transferSummary
+		}
+		received: transfers(orderBy: timestamp, orderDirection: desc, first: $first, where: {to: $account}) {
+			...transferSummary
+		}
+	}
+
+	fragment dealSummary on Deal {
+		id
+		timestamp
+		app {
+			id
+		}
+		workerpool {
+			id
+		}
+		appPrice
+		datasetPrice
+		workerpoolPrice
+		botSize
+		completedTasksCount
+		claimedTasksCount
+	}
+
+	fragment transferSummary on Transfer {
+		id
+		from {
+			id
+		}
+		to {
+			id
+		}
+		value
+		timestamp
+	}`
+
+// GetAccountActivity fetches the latest PoCo activity of an account, at most limit items per kind,
+// together with the vouchers it owns
+func (c *Client) GetAccountActivity(ctx context.Context, account string, limit int) (AccountActivity, error) {
+	limit = normalizeLimit(limit)
+	account = strings.ToLower(account)
+
+	var response struct {
+		Data struct {
+			AccountActivity
+			Sent     []Transfer `json:"sent"`
+			Received []Transfer `json:"received"`
+		} `json:"data"`
+	}
+
+	err := c.fetchGraphQLData(ctx, PocoSubgraph, accountActivityQuery, map[string]interface{}{
+		"id":      account,
+		"account": account,
+		"first":   limit,
+	}, &response)
+	if err != nil {
+		return AccountActivity{}, err
+	}
+
+	activity := response.Data.AccountActivity
+	activity.Transfers = mergeTransfers(response.Data.Sent, response.Data.Received, limit)
+
+	for voucher, err := range c.Vouchers(ctx, VoucherFilter{Owner: account, IncludeZeroBalance: true}) {
+		if err != nil {
+			return activity, err
+		}
+		activity.Vouchers = append(activity.Vouchers, voucher)
+	}
+
+	return activity, nil
+}
+
+// mergeTransfers merges sent and received transfers, most recent first, keeping at most limit transfers
+func mergeTransfers(sent, received []Transfer, limit int) []Transfer {
+	transfers := slices.Clone(sent)
+
+	// A transfer to self is both sent and received
+	for _, transfer := range received {
+		if !slices.ContainsFunc(sent, func(t Transfer) bool { return t.ID == transfer.ID }) {
+			transfers = append(transfers, transfer)
+		}
+	}
+
+	slices.SortStableFunc(transfers, func(a, b Transfer) int {
+		return b.Timestamp.Compare(a.Timestamp.Time)
+	})
+
+	return transfers[:min(len(transfers), limit)]
+}
@@ -0,0 +1,94 @@
+package thegraph
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestGetAccountActivity(t *testing.T) {
+	pocoResponse := `{
+		"data": {
+			"account": {"id": "0xme", "balance": "12.5", "frozen": "1"},
+			"requestedDeals": [{"id": "0xdeal1", "botSize": "1", "completedTasksCount": "1", "appPrice": "0.1"}],
+			"scheduledDeals": [],
+			"contributions": [{"id": "0xc1", "status": "PROVED", "task": {"id": "0xtask", "status": "COMPLETED"}}],
+			"apps": [{"id": "0xapp", "name": "my-app"}],
+			"datasets": [],
+			"workerpools": [{"id": "0xpool", "description": "my-pool"}],
+			"sent": [
+				{"id": "0xt1", "from": {"id": "0xme"}, "to": {"id": "0xother"}, "value": "1", "timestamp": "100"},
+				{"id": "0xself", "from": {"id": "0xme"}, "to": {"id": "0xme"}, "value": "2", "timestamp": "300"}
+			],
+			"received": [
+				{"id": "0xt2", "from": {"id": "0xother"}, "to": {"id": "0xme"}, "value": "3", "timestamp": "200"},
+				{"id": "0xself", "from": {"id": "0xme"}, "to": {"id": "0xme"}, "value": "2", "timestamp": "300"}
+			]
+		}
+	}`
+	voucherResponse := `{"data": {"vouchers": [{"id": "0xvoucher", "owner": {"id": "0xme"}, "balance": "0"}]}}`
+
+	var endpoints []string
+	var pocoPayload graphQLRequest
+
+	client := newMockedClient(func(req *http.Request) (*http.Response, error) {
+		endpoints = append(endpoints, req.URL.Path)
+
+		if strings.HasSuffix(req.URL.Path, VoucherEndpoint) {
+			return newStatusResponse(200, voucherResponse), nil
+		}
+
+		if err := json.NewDecoder(req.Body).Decode(&pocoPayload); err != nil {
+			t.Fatalf("failed to decode request body: %v", err)
+		}
+
+		return newStatusResponse(200, pocoResponse), nil
+	})
+
+	activity, err := client.GetAccountActivity(context.Background(), "0xME", 10)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if len(endpoints) != 2 {
+		t.Errorf("expected a PoCo and a voucher request, got %v", endpoints)
+	}
+
+	if pocoPayload.Variables["account"] != "0xme" || pocoPayload.Variables["id"] != "0xme" {
+		t.Errorf("expected account and id '0xme', got %v", pocoPayload.Variables)
+	}
+
+	if activity.Account == nil || activity.Account.Balance.String() != "12.5" {
+		t.Errorf("unexpected account %+v", activity.Account)
+	}
+
+	if len(activity.RequestedDeals) != 1 || len(activity.Contributions) != 1 || len(activity.Apps) != 1 || len(activity.Workerpools) != 1 {
+		t.Errorf("unexpected activity %+v", activity)
+	}
+
+	if len(activity.Vouchers) != 1 || activity.Vouchers[0].ID != "0xvoucher" {
+		t.Errorf("unexpected vouchers %+v", activity.Vouchers)
+	}
+
+	ids := make([]string, 0, len(activity.Transfers))
+	for _, transfer := range activity.Transfers {
+		ids = append(ids, transfer.ID)
+	}
+
+	if strings.Join(ids, ",") != "0xself,0xt2,0xt1" {
+		t.Errorf("expected transfers merged by date without duplicates, got %v", ids)
+	}
+}
+
+func TestMergeTransfersLimit(t *testing.T) {
+	sent := []Transfer{{ID: "a", Timestamp: Timestamp{time.Unix(1, 0)}}, {ID: "b", Timestamp: Timestamp{time.Unix(3, 0)}}}
+	received := []Transfer{{ID: "c", Timestamp: Timestamp{time.Unix(2, 0)}}}
+
+	transfers := mergeTransfers(sent, received, 2)
+	if len(transfers) != 2 || transfers[0].ID != "b" || transfers[1].ID != "c" {
+		t.Errorf("unexpected transfers %+v", transfers)
+	}
+}
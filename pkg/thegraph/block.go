@@ -0,0 +1,153 @@
+package thegraph
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const block_hash_regex = `^0x[0-9a-fA-F]{64}$`
+
+var blockHashRegexp = regexp.MustCompile(block_hash_regex)
+
+var errInvalidBlock = errors.New("invalid block, expected a block number or a 0x prefixed block hash")
+
+// blockUnavailableMessages are the graph-node error messages telling a block can not be queried
+var blockUnavailableMessages = []string{
+	"pruned",
+	"earliest block",
+	"not yet available",
+	"no block with",
+	"block not found",
+}
+
+// Block pins queries to a block, the zero Block is the latest indexed block
+type Block struct {
+	Number uint64
+	Hash   string
+}
+
+// BlockNumber returns a Block pinned to a block number
+func BlockNumber(number uint64) Block {
+	return Block{Number: number}
+}
+
+// ParseBlock parses a block number or a block hash, an empty string is the latest indexed block
+func ParseBlock(value string) (Block, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return Block{}, nil
+	}
+
+	if strings.HasPrefix(value, "0x") {
+		if !blockHashRegexp.MatchString(value) {
+			return Block{}, errInvalidBlock
+		}
+
+		return Block{Hash: strings.ToLower(value)}, nil
+	}
+
+	number, err := strconv.ParseUint(value, 10, 64)
+	if err != nil || number == 0 {
+		return Block{}, errInvalidBlock
+	}
+
+	return Block{Number: number}, nil
+}
+
+// IsLatest reports whether the block is the latest indexed block
+func (b Block) IsLatest() bool {
+	return b.Number == 0 && b.Hash == ""
+}
+
+func (b Block) String() string {
+	switch {
+	case b.Hash != "":
+		return b.Hash
+	case b.Number != 0:
+		return strconv.FormatUint(b.Number, 10)
+	default:
+		return "latest"
+	}
+}
+
+// argument returns the GraphQL block argument pinning an entity selection to the block
+func (b Block) argument() string {
+	if b.Hash != "" {
+		return fmt.Sprintf(`block: {hash: "%s"}`, b.Hash)
+	}
+
+	return fmt.Sprintf("block: {number: %d}", b.Number)
+}
+
+// AtBlock returns a copy of the client whose queries read the subgraphs as of block
+func (c *Client) AtBlock(block Block) *Client {
+	pinned := *c
+	pinned.block = block
+
+	return &pinned
+}
+
+// Block returns the block the client queries are pinned to
+func (c *Client) Block() Block {
+	return c.block
+}
+
+// pinQuery injects the block argument in every root entity selection of a GraphQL document,
+// selections already pinned by the document and introspection fields are left untouched
+func pinQuery(query string, block Block) (string, error) {
+	if block.IsLatest() {
+		return query, nil
+	}
+
+	doc, err := parseDocument(query)
+	if err != nil {
+		return "", err
+	}
+
+	var sb strings.Builder
+	last := 0
+
+	for _, op := range doc.operations {
+		for _, field := range op.selections {
+			if field.name == "" || strings.HasPrefix(field.name, "__") {
+				continue
+			}
+
+			if field.argumentsOpen < 0 {
+				sb.WriteString(query[last:field.nameEnd])
+				sb.WriteString("(" + block.argument() + ")")
+				last = field.nameEnd
+
+				continue
+			}
+
+			if _, pinned := field.arguments["block"]; pinned {
+				continue
+			}
+
+			sb.WriteString(query[last:field.argumentsOpen])
+			sb.WriteString(block.argument() + ", ")
+			last = field.argumentsOpen
+		}
+	}
+	sb.WriteString(query[last:])
+
+	return sb.String(), nil
+}
+
+// isBlockUnavailable reports whether GraphQL errors tell the requested block can not be queried
+func isBlockUnavailable(errs []GraphQLError) bool {
+	for _, gqlErr := range errs {
+		message := strings.ToLower(gqlErr.Message)
+		for _, unavailable := range blockUnavailableMessages {
+			if strings.Contains(message, unavailable) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
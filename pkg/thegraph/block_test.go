@@ -0,0 +1,152 @@
+package thegraph
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestParseBlock(t *testing.T) {
+	hash := "0x" + strings.Repeat("Ab", 32)
+
+	tests := []struct {
+		value   string
+		want    Block
+		wantErr bool
+	}{
+		{value: "", want: Block{}},
+		{value: " 12345 ", want: Block{Number: 12345}},
+		{value: hash, want: Block{Hash: strings.ToLower(hash)}},
+		{value: "0", wantErr: true},
+		{value: "-1", wantErr: true},
+		{value: "0x1234", wantErr: true},
+		{value: "latest", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		block, err := ParseBlock(tt.value)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseBlock(%q): unexpected error %v", tt.value, err)
+			continue
+		}
+
+		if block != tt.want {
+			t.Errorf("ParseBlock(%q): expected %+v, got %+v", tt.value, tt.want, block)
+		}
+	}
+}
+
+func TestPinQuery(t *testing.T) {
+	block := BlockNumber(42)
+
+	tests := []struct {
+		name  string
+		query string
+		want  string
+	}{
+		{
+			name:  "field without arguments",
+			query: `{ vouchers { id } }`,
+			want:  `{ vouchers(block: {number: 42}) { id } }`,
+		},
+		{
+			name:  "field with arguments and alias",
+			query: `query q($first: Int!) { sent: transfers(first: $first) { id } }`,
+			want:  `query q($first: Int!) { sent: transfers(block: {number: 42}, first: $first) { id } }`,
+		},
+		{
+			name:  "several root fields and nested selections",
+			query: `{ account(id: "0x1") { deals(first: 1) { id } } _meta { block { number } } }`,
+			want:  `{ account(block: {number: 42}, id: "0x1") { deals(first: 1) { id } } _meta(block: {number: 42}) { block { number } } }`,
+		},
+		{
+			name:  "already pinned field",
+			query: `{ vouchers(block: {number: 1}, where: {block: "x"}) { id } }`,
+			want:  `{ vouchers(block: {number: 1}, where: {block: "x"}) { id } }`,
+		},
+		{
+			name:  "fragments and introspection",
+			query: "query {\n  # deals { id }\n  __typename\n  deals { ...deal }\n}\nfragment deal on Deal { app { id } }",
+			want:  "query {\n  # deals { id }\n  __typename\n  deals(block: {number: 42}) { ...deal }\n}\nfragment deal on Deal { app { id } }",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := pinQuery(tt.query, block)
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+
+			if got != tt.want {
+				t.Errorf("expected\n%s\ngot\n%s", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestPinQueryByHash(t *testing.T) {
+	got, err := pinQuery(`{ vouchers { id } }`, Block{Hash: "0xabc"})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if got != `{ vouchers(block: {hash: "0xabc"}) { id } }` {
+		t.Errorf("unexpected query %s", got)
+	}
+}
+
+func TestPinQueryInvalidDocument(t *testing.T) {
+	_, err := pinQuery(`{ vouchers { id }`, BlockNumber(42))
+	if !errors.Is(err, errInvalidDocument) {
+		t.Errorf("expected errInvalidDocument, got %v", err)
+	}
+}
+
+func TestAtBlock(t *testing.T) {
+	var payload graphQLRequest
+
+	client := newMockedClient(func(req *http.Request) (*http.Response, error) {
+		if err := json.NewDecoder(req.Body).Decode(&payload); err != nil {
+			t.Fatalf("failed to decode request body: %v", err)
+		}
+
+		return newStatusResponse(200, `{"data": {"vouchers": []}}`), nil
+	})
+
+	if _, err := client.AtBlock(BlockNumber(42)).GetVouchersPage(context.Background(), VoucherFilter{}, "", 10); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if !strings.Contains(payload.Query, "vouchers(block: {number: 42}, ") {
+		t.Errorf("expected the query to be pinned to block 42, got %s", payload.Query)
+	}
+
+	if _, err := client.GetVouchersPage(context.Background(), VoucherFilter{}, "", 10); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if strings.Contains(payload.Query, "block:") {
+		t.Errorf("expected the original client to query the latest block, got %s", payload.Query)
+	}
+}
+
+func TestAtBlockPruned(t *testing.T) {
+	client := newMockedClient(func(req *http.Request) (*http.Response, error) {
+		return newStatusResponse(200, `{"errors": [{"message": "requested block 42 is earlier than the earliest block 1000 available"}]}`), nil
+	})
+
+	_, err := client.AtBlock(BlockNumber(42)).GetVouchersPage(context.Background(), VoucherFilter{}, "", 10)
+
+	var blockErr *BlockUnavailableError
+	if !errors.As(err, &blockErr) {
+		t.Fatalf("expected a BlockUnavailableError, got %v", err)
+	}
+
+	if blockErr.Block.Number != 42 || !errors.Is(err, errOnTheGraph) {
+		t.Errorf("unexpected error %v", err)
+	}
+}
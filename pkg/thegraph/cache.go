@@ -0,0 +1,357 @@
+package thegraph
+
+import (
+	"container/list"
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+)
+
+const (
+	defaultCacheSize   = 500
+	defaultCacheTTL    = 30 * time.Second
+	blockCheckInterval = 5 * time.Second
+	blockQuery         = `{ _meta { block { number } } }`
+)
+
+// CacheStats are the counters of a response cache
+type CacheStats struct {
+	Hits          uint64 `json:"hits"`
+	Misses        uint64 `json:"misses"`
+	Coalesced     uint64 `json:"coalesced"`
+	Evictions     uint64 `json:"evictions"`
+	Invalidations uint64 `json:"invalidations"`
+	Entries       int    `json:"entries"`
+}
+
+// Cache is an LRU cache of successful GraphQL responses keyed on the subgraph URL, the query and its variables.
+// Concurrent identical requests are coalesced into a single one
+type Cache struct {
+	mu       sync.Mutex
+	capacity int
+	ttl      time.Duration
+	ttls     map[string]time.Duration
+	entries  map[string]*list.Element
+	lru      *list.List
+	group    singleflight.Group
+	stats    CacheStats
+
+	// operations memoizes the operation name of the queries, it is cleared once it holds capacity queries
+	operations map[string]string
+
+	// blockAware purges the responses of a subgraph once it indexes a new block
+	blockAware bool
+	blocks     map[string]indexedBlock
+	now        func() time.Time
+}
+
+type cacheEntry struct {
+	key       string
+	url       string
+	data      []byte
+	expiresAt time.Time
+}
+
+// indexedBlock is the last block seen for a subgraph URL
+type indexedBlock struct {
+	number    int64
+	checkedAt time.Time
+}
+
+// NewCache creates a response cache of capacity entries kept for ttl,
+// zero values select 500 entries and 30 seconds
+func NewCache(capacity int, ttl time.Duration) *Cache {
+	if capacity <= 0 {
+		capacity = defaultCacheSize
+	}
+
+	if ttl <= 0 {
+		ttl = defaultCacheTTL
+	}
+
+	return &Cache{
+		capacity: capacity,
+		ttl:      ttl,
+		// The indexing status must be fresh and the schemas have their own cache
+		ttls: map[string]time.Duration{
+			"indexingStatus": 0,
+			"introspection":  0,
+		},
+		operations: make(map[string]string),
+		entries:    make(map[string]*list.Element),
+		lru:        list.New(),
+		blocks:     make(map[string]indexedBlock),
+		now:        time.Now,
+	}
+}
+
+// WithCache caches the responses of the client
+func WithCache(cache *Cache) Option {
+	return func(c *Client) {
+		c.cache = cache
+	}
+}
+
+// SetTTL sets how long the responses of a named GraphQL operation, e.g. "voucherTypes", are kept,
+// a zero or negative ttl disables caching for the operation
+func (cache *Cache) SetTTL(operation string, ttl time.Duration) {
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+
+	cache.ttls[operation] = ttl
+}
+
+// InvalidateOnNewBlock purges the cached responses of a subgraph when its indexed block advances,
+// the indexed block is checked at most every 5 seconds
+func (cache *Cache) InvalidateOnNewBlock() {
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+
+	cache.blockAware = true
+}
+
+// Stats returns the counters of the cache
+func (cache *Cache) Stats() CacheStats {
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+
+	stats := cache.stats
+	stats.Entries = cache.lru.Len()
+
+	return stats
+}
+
+// CacheStats returns the counters of the client cache, found is false when the client has no cache
+func (c *Client) CacheStats() (stats CacheStats, found bool) {
+	if c.cache == nil {
+		return CacheStats{}, false
+	}
+
+	return c.cache.Stats(), true
+}
+
+// cacheKey identifies a request, the variables are encoded by encoding/json which sorts map keys
+func cacheKey(url, query string, variables map[string]interface{}) (string, error) {
+	encoded, err := json.Marshal(variables)
+	if err != nil {
+		return "", err
+	}
+
+	return url + "\x00" + query + "\x00" + string(encoded), nil
+}
+
+// operationTTL returns how long the responses of a query are kept, the query is parsed once to find its operation
+func (cache *Cache) operationTTL(query string) time.Duration {
+	cache.mu.Lock()
+	name, found := cache.operations[query]
+	cache.mu.Unlock()
+
+	if !found {
+		// The documents without a single operation get the default TTL
+		if doc, err := parseDocument(query); err == nil && len(doc.operations) == 1 {
+			name = doc.operations[0].name
+		}
+	}
+
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+
+	if !found {
+		if len(cache.operations) >= cache.capacity {
+			clear(cache.operations)
+		}
+		cache.operations[query] = name
+	}
+
+	if ttl, found := cache.ttls[name]; found {
+		return ttl
+	}
+
+	return cache.ttl
+}
+
+func (cache *Cache) get(key string) ([]byte, bool) {
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+
+	element, found := cache.entries[key]
+	if !found {
+		return nil, false
+	}
+
+	entry := element.Value.(*cacheEntry)
+	if cache.now().After(entry.expiresAt) {
+		cache.remove(element)
+		return nil, false
+	}
+
+	cache.lru.MoveToFront(element)
+
+	return entry.data, true
+}
+
+func (cache *Cache) set(key, url string, data []byte, ttl time.Duration) {
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+
+	entry := &cacheEntry{key: key, url: url, data: data, expiresAt: cache.now().Add(ttl)}
+
+	if element, found := cache.entries[key]; found {
+		element.Value = entry
+		cache.lru.MoveToFront(element)
+
+		return
+	}
+
+	cache.entries[key] = cache.lru.PushFront(entry)
+
+	for cache.lru.Len() > cache.capacity {
+		cache.remove(cache.lru.Back())
+		cache.stats.Evictions++
+	}
+}
+
+// remove drops an entry, the caller holds the lock
+func (cache *Cache) remove(element *list.Element) {
+	cache.lru.Remove(element)
+	delete(cache.entries, element.Value.(*cacheEntry).key)
+}
+
+// needsBlockCheck reports whether the indexed block of a subgraph should be checked again,
+// the check is then claimed so that concurrent requests do not repeat it
+func (cache *Cache) needsBlockCheck(url string) bool {
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+
+	if !cache.blockAware {
+		return false
+	}
+
+	block := cache.blocks[url]
+	if cache.now().Sub(block.checkedAt) < blockCheckInterval {
+		return false
+	}
+
+	block.checkedAt = cache.now()
+	cache.blocks[url] = block
+
+	return true
+}
+
+// observeBlock records the indexed block of a subgraph and purges its responses when the block advanced
+func (cache *Cache) observeBlock(url string, number int64) {
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+
+	previous := cache.blocks[url]
+	cache.blocks[url] = indexedBlock{number: number, checkedAt: cache.now()}
+
+	// The first block seen for a subgraph invalidates nothing
+	if previous.number == 0 || number <= previous.number {
+		return
+	}
+
+	for element := cache.lru.Front(); element != nil; {
+		next := element.Next()
+		if element.Value.(*cacheEntry).url == url {
+			cache.remove(element)
+			cache.stats.Invalidations++
+		}
+		element = next
+	}
+}
+
+func (cache *Cache) count(counter *uint64) {
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+
+	*counter++
+}
+
+// fetchCached returns the cached response of a request or fetches it once for all concurrent callers
+func (c *Client) fetchCached(ctx context.Context, target target, query string, variables map[string]interface{}) ([]byte, error) {
+	cache := c.cache
+
+	ttl := cache.operationTTL(query)
+	if ttl <= 0 {
+		return c.fetchData(ctx, target, query, variables)
+	}
+
+	key, err := cacheKey(target.url, query, variables)
+	if err != nil {
+		return c.fetchData(ctx, target, query, variables)
+	}
+
+	if cache.needsBlockCheck(target.url) {
+		var meta struct {
+			Data struct {
+				Meta Meta `json:"_meta"`
+			} `json:"data"`
+		}
+
+		// A failed check keeps the cached responses until they expire
+		if data, err := c.fetchData(ctx, target, blockQuery, nil); err == nil && json.Unmarshal(data, &meta) == nil {
+			cache.observeBlock(target.url, meta.Data.Meta.Block.Number.IntPart())
+		}
+	}
+
+	if data, found := cache.get(key); found {
+		cache.count(&cache.stats.Hits)
+		return data, nil
+	}
+
+	// The shared request outlives a cancelled caller so that the other callers still get the response
+	leader := false
+	result := cache.group.DoChan(key, func() (interface{}, error) {
+		leader = true
+		cache.count(&cache.stats.Misses)
+
+		sharedCtx, cancel := c.sharedContext(ctx)
+		defer cancel()
+
+		data, err := c.fetchData(sharedCtx, target, query, variables)
+		if err == nil {
+			cache.set(key, target.url, data, ttl)
+		}
+
+		return data, err
+	})
+
+	select {
+	case <-ctx.Done():
+		return nil, &TransportError{Err: ctx.Err()}
+	case res := <-result:
+		if !leader {
+			cache.count(&cache.stats.Coalesced)
+		}
+
+		if res.Err != nil {
+			return nil, res.Err
+		}
+
+		return res.Val.([]byte), nil
+	}
+}
+
+// sharedContext is the context of a request shared by several callers, it is not cancelled with ctx but
+// keeps its deadline, or is bounded by the query timeout, else the HTTP timeout, when ctx has none
+func (c *Client) sharedContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	shared := context.WithoutCancel(ctx)
+
+	if deadline, found := ctx.Deadline(); found {
+		return context.WithDeadline(shared, deadline)
+	}
+
+	timeout := c.queryLimits.Timeout
+	if timeout <= 0 && c.httpClient != nil {
+		timeout = c.httpClient.Timeout
+	}
+	if timeout <= 0 {
+		timeout = defaulHttpTimeout
+	}
+
+	return context.WithTimeout(shared, timeout)
+}
@@ -0,0 +1,223 @@
+package thegraph
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func newCachedClient(cache *Cache, fn func(req *http.Request, payload graphQLRequest) (*http.Response, error)) *Client {
+	return newMockedClient(func(req *http.Request) (*http.Response, error) {
+		var payload graphQLRequest
+		if err := json.NewDecoder(req.Body).Decode(&payload); err != nil {
+			return nil, err
+		}
+
+		return fn(req, payload)
+	}, WithCache(cache))
+}
+
+func TestCacheHitsAndMisses(t *testing.T) {
+	var calls atomic.Int32
+
+	client := newCachedClient(NewCache(10, time.Minute), func(req *http.Request, payload graphQLRequest) (*http.Response, error) {
+		calls.Add(1)
+		return newStatusResponse(200, `{"data": {"vouchers": [{"id": "0x1"}]}}`), nil
+	})
+
+	for range 3 {
+		page, err := client.GetVouchersPage(context.Background(), VoucherFilter{Owner: "0xowner"}, "", 10)
+		if err != nil || len(page.Items) != 1 {
+			t.Fatalf("unexpected page %+v, %v", page, err)
+		}
+	}
+
+	if _, err := client.GetVouchersPage(context.Background(), VoucherFilter{Owner: "0xother"}, "", 10); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if calls.Load() != 2 {
+		t.Errorf("expected 2 requests, got %d", calls.Load())
+	}
+
+	stats, _ := client.CacheStats()
+	if stats.Hits != 2 || stats.Misses != 2 || stats.Entries != 2 {
+		t.Errorf("unexpected stats %+v", stats)
+	}
+}
+
+func TestCacheExpirationAndEviction(t *testing.T) {
+	cache := NewCache(2, time.Minute)
+	now := time.Now()
+	cache.now = func() time.Time { return now }
+
+	cache.set("a", "url", []byte("a"), time.Minute)
+	cache.set("b", "url", []byte("b"), time.Second)
+
+	// a becomes the most recently used entry, b is evicted by c
+	if _, found := cache.get("a"); !found {
+		t.Fatal("expected a to be cached")
+	}
+	cache.set("c", "url", []byte("c"), time.Minute)
+
+	if _, found := cache.get("b"); found {
+		t.Error("expected b to be evicted")
+	}
+
+	now = now.Add(2 * time.Minute)
+	if _, found := cache.get("a"); found {
+		t.Error("expected a to be expired")
+	}
+
+	if stats := cache.Stats(); stats.Evictions != 1 || stats.Entries != 1 {
+		t.Errorf("unexpected stats %+v", stats)
+	}
+}
+
+func TestCacheOperationTTL(t *testing.T) {
+	var calls atomic.Int32
+
+	cache := NewCache(10, time.Minute)
+	cache.SetTTL("vouchers", 0)
+
+	client := newCachedClient(cache, func(req *http.Request, payload graphQLRequest) (*http.Response, error) {
+		calls.Add(1)
+		return newStatusResponse(200, `{"data": {"vouchers": []}}`), nil
+	})
+
+	for range 2 {
+		if _, err := client.GetVouchersPage(context.Background(), VoucherFilter{}, "", 10); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+	}
+
+	if calls.Load() != 2 {
+		t.Errorf("expected the vouchers operation not to be cached, got %d requests", calls.Load())
+	}
+
+	// The query is parsed once, a later TTL still applies to it
+	if len(cache.operations) != 1 {
+		t.Errorf("expected the operation of 1 query to be memoized, got %v", cache.operations)
+	}
+
+	cache.SetTTL("vouchers", time.Minute)
+	for range 2 {
+		if _, err := client.GetVouchersPage(context.Background(), VoucherFilter{}, "", 10); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+	}
+
+	if calls.Load() != 3 {
+		t.Errorf("expected the vouchers operation to be cached, got %d requests", calls.Load())
+	}
+}
+
+func TestCacheCoalescesConcurrentRequests(t *testing.T) {
+	var calls atomic.Int32
+	started := make(chan struct{})
+	release := make(chan struct{})
+
+	client := newCachedClient(NewCache(10, time.Minute), func(req *http.Request, payload graphQLRequest) (*http.Response, error) {
+		if calls.Add(1) == 1 {
+			close(started)
+		}
+		<-release
+		return newStatusResponse(200, `{"data": {"vouchers": []}}`), nil
+	})
+
+	var wg sync.WaitGroup
+	for range 5 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := client.GetVouchersPage(context.Background(), VoucherFilter{}, "", 10); err != nil {
+				t.Errorf("expected no error, got %v", err)
+			}
+		}()
+	}
+
+	// The requests arriving after the response are served from the cache instead of joining the in-flight one
+	<-started
+	close(release)
+	wg.Wait()
+
+	if calls.Load() != 1 {
+		t.Errorf("expected a single request, got %d", calls.Load())
+	}
+
+	if stats, _ := client.CacheStats(); stats.Misses != 1 || stats.Hits+stats.Coalesced != 4 {
+		t.Errorf("unexpected stats %+v", stats)
+	}
+}
+
+func TestCacheDoesNotKeepErrors(t *testing.T) {
+	var calls atomic.Int32
+
+	client := newCachedClient(NewCache(10, time.Minute), func(req *http.Request, payload graphQLRequest) (*http.Response, error) {
+		if calls.Add(1) == 1 {
+			return newStatusResponse(200, `{"errors": [{"message": "boom"}]}`), nil
+		}
+		return newStatusResponse(200, `{"data": {"vouchers": []}}`), nil
+	})
+
+	if _, err := client.GetVouchersPage(context.Background(), VoucherFilter{}, "", 10); err == nil {
+		t.Fatal("expected an error")
+	}
+
+	if _, err := client.GetVouchersPage(context.Background(), VoucherFilter{}, "", 10); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+}
+
+func TestCacheInvalidateOnNewBlock(t *testing.T) {
+	var block, calls atomic.Int32
+	block.Store(100)
+
+	cache := NewCache(10, time.Hour)
+	cache.InvalidateOnNewBlock()
+	now := time.Now()
+	cache.now = func() time.Time { return now }
+
+	client := newCachedClient(cache, func(req *http.Request, payload graphQLRequest) (*http.Response, error) {
+		if strings.Contains(payload.Query, "_meta") {
+			return newStatusResponse(200, `{"data": {"_meta": {"block": {"number": `+strconv.Itoa(int(block.Load()))+`}}}}`), nil
+		}
+
+		calls.Add(1)
+		return newStatusResponse(200, `{"data": {"vouchers": []}}`), nil
+	})
+
+	fetch := func() {
+		if _, err := client.GetVouchersPage(context.Background(), VoucherFilter{}, "", 10); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+	}
+
+	fetch()
+	fetch()
+
+	// The block is not checked again before the interval
+	block.Store(101)
+	fetch()
+
+	if calls.Load() != 1 {
+		t.Errorf("expected a single request before the block check, got %d", calls.Load())
+	}
+
+	now = now.Add(blockCheckInterval)
+	fetch()
+
+	if calls.Load() != 2 {
+		t.Errorf("expected the new block to invalidate the cache, got %d requests", calls.Load())
+	}
+
+	if stats := cache.Stats(); stats.Invalidations != 1 {
+		t.Errorf("unexpected stats %+v", stats)
+	}
+}
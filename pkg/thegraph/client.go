@@ -2,24 +2,45 @@ package thegraph
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"io"
 	"net/http"
+	"regexp"
+	"slices"
+	"strings"
 	"time"
 )
 
 const (
 	DEFAULT_URL       = "https://thegraph.bellecour.iex.ec/subgraphs/name/bellecour"
 	defaulHttpTimeout = 10 * time.Second
+	endpoint_regex    = `^(/[A-Za-z0-9_\-]+)*$`
+	defaultPageSize   = 100
+	maxPageSize       = 1000
 )
 
-var errOnTheGraph = errors.New("error while trying to fetch data from TheGraph")
+var (
+	errOnTheGraph      = errors.New("error while trying to fetch data from TheGraph")
+	errInvalidEndpoint = errors.New("invalid subgraph endpoint")
+)
+
+var endpointRegexp = regexp.MustCompile(endpoint_regex)
 
 // Client represents an TheGraph API client
 type Client struct {
-	httpClient *http.Client
-	baseURL    string
+	httpClient  *http.Client
+	baseURL     string
+	retryPolicy RetryPolicy
+	breaker     *CircuitBreaker
+	headers     http.Header
+	subgraphs   map[string]Subgraph
+	schemas     *schemaCache
+	queryLimits QueryLimits
+	cache       *Cache
+	block       Block
 }
 
 // NewDefaultClient creates a new TheGraph client with default URL
@@ -28,69 +49,191 @@ func NewDefaultClient() *Client {
 }
 
 // NewClient creates a new TheGraph client with specific URL
-func NewClient(url string) *Client {
-	return &Client{
+func NewClient(url string, opts ...Option) *Client {
+	client := &Client{
 		baseURL: url,
 		httpClient: &http.Client{
 			Timeout: defaulHttpTimeout,
 		},
+		retryPolicy: DefaultRetryPolicy,
+		breaker:     NewCircuitBreaker(defaultBreakerThreshold, defaultBreakerCooldown),
+		headers:     make(http.Header),
+		schemas:     newSchemaCache(),
+		queryLimits: DefaultQueryLimits,
 	}
+
+	for _, opt := range opts {
+		opt(client)
+	}
+
+	return client
 }
 
-// GetVouchers fetches vouchers data from TheGraph
-func (c *Client) GetVouchers() (VoucherResponse, error) {
-	query := `
-	{
-		vouchers(orderBy: expiration, orderDirection: desc, first: 500, where: {balance_gt: 0}) {
-			voucherType {
-				id
-				description
-			}
-			id
-			owner {
-				id
-			}
-			expiration
-			value
-			balance
-		}
-	}`
+// Query executes a GraphQL document with its variables against a subgraph, given by name
+// (e.g. "voucher") or by path under baseURL (e.g. "/iexec-voucher", or "" for baseURL itself),
+// and returns the raw JSON data
+func (c *Client) Query(ctx context.Context, endpoint, query string, variables map[string]interface{}) (json.RawMessage, error) {
+	var response QueryResponse
+	err := c.fetchGraphQLData(ctx, endpoint, query, variables, &response)
 
-	var vouchers VoucherResponse
-	err := c.fetchGraphQLData("/iexec-voucher", query, &vouchers)
+	return response.Data, err
+}
+
+// normalizeEndpoint ensures the endpoint is a plain path under baseURL
+func normalizeEndpoint(endpoint string) (string, error) {
+	endpoint = strings.TrimSpace(endpoint)
+	if endpoint != "" && !strings.HasPrefix(endpoint, "/") {
+		endpoint = "/" + endpoint
+	}
+	endpoint = strings.TrimSuffix(endpoint, "/")
+
+	if !endpointRegexp.MatchString(endpoint) {
+		return "", errInvalidEndpoint
+	}
+
+	return endpoint, nil
+}
+
+// normalizeLimit bounds a page size to what TheGraph accepts
+func normalizeLimit(limit int) int {
+	if limit <= 0 {
+		return defaultPageSize
+	}
 
-	return vouchers, err
+	return min(limit, maxPageSize)
 }
 
 // fetchGraphQLData is a helper function to execute GraphQL queries
-func (c *Client) fetchGraphQLData(endpoint, query string, result interface{}) error {
-	jsonPayload, err := json.Marshal(map[string]string{"query": query})
+func (c *Client) fetchGraphQLData(ctx context.Context, endpoint, query string, variables map[string]interface{}, result interface{}) error {
+	target, err := c.resolve(endpoint)
 	if err != nil {
-		return errOnTheGraph
+		return err
 	}
 
-	req, err := http.NewRequest("POST", c.baseURL+endpoint, bytes.NewBuffer(jsonPayload))
+	query, err = pinQuery(query, c.block)
 	if err != nil {
-		return errOnTheGraph
+		return err
 	}
 
+	var data []byte
+	if c.cache != nil {
+		data, err = c.fetchCached(ctx, target, query, variables)
+	} else {
+		data, err = c.fetchData(ctx, target, query, variables)
+	}
+	if err != nil {
+		return err
+	}
+
+	err = json.Unmarshal(data, &result)
+	if err != nil {
+		return &DecodeError{Err: err}
+	}
+
+	return nil
+}
+
+// fetchData sends a GraphQL request, retrying on transient failures, and returns a response body without GraphQL errors
+func (c *Client) fetchData(ctx context.Context, target target, query string, variables map[string]interface{}) ([]byte, error) {
+	jsonPayload, err := json.Marshal(graphQLRequest{Query: query, Variables: variables})
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", errOnTheGraph, err)
+	}
+
+	var data []byte
+
+	for attempt := 1; ; attempt++ {
+		if c.breaker != nil && !c.breaker.allow(target.url) {
+			return nil, errCircuitOpen
+		}
+
+		data, err = c.doRequest(ctx, target, jsonPayload)
+		if err != nil && ctx.Err() != nil {
+			// A request aborted by the caller says nothing about TheGraph health
+			if c.breaker != nil {
+				c.breaker.abandon(target.url)
+			}
+
+			return nil, err
+		}
+
+		if c.breaker != nil {
+			c.breaker.record(target.url, err)
+		}
+
+		if err == nil {
+			break
+		}
+
+		delay, retry := c.retryPolicy.next(attempt, err)
+		if !retry {
+			return nil, err
+		}
+
+		if waitErr := sleepContext(ctx, delay); waitErr != nil {
+			return nil, &TransportError{Err: waitErr}
+		}
+	}
+
+	var envelope graphQLResponse
+	if err = json.Unmarshal(data, &envelope); err != nil {
+		return nil, &DecodeError{Err: err}
+	}
+
+	if len(envelope.Errors) > 0 {
+		if !c.block.IsLatest() && isBlockUnavailable(envelope.Errors) {
+			return nil, &BlockUnavailableError{Block: c.block, Errors: envelope.Errors}
+		}
+
+		return nil, &GraphQLErrors{Errors: envelope.Errors}
+	}
+
+	return data, nil
+}
+
+// doRequest sends a single GraphQL request and returns the body of a successful response
+func (c *Client) doRequest(ctx context.Context, target target, jsonPayload []byte) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, "POST", target.url, bytes.NewReader(jsonPayload))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", errOnTheGraph, err)
+	}
+
+	// The client headers, e.g. the API key, are only sent to TheGraph, not to self-hosted subgraphs
+	if target.base {
+		for key, values := range c.headers {
+			req.Header[key] = slices.Clone(values)
+		}
+	}
+	for key, values := range target.headers {
+		req.Header[key] = slices.Clone(values)
+	}
 	req.Header.Set("Content-Type", "application/json")
 
-	resp, err := c.httpClient.Do(req)
+	httpClient := c.httpClient
+	if target.timeout > 0 {
+		withTimeout := *httpClient
+		withTimeout.Timeout = target.timeout
+		httpClient = &withTimeout
+	}
+
+	resp, err := httpClient.Do(req)
 	if err != nil {
-		return errOnTheGraph
+		return nil, &TransportError{Err: err}
 	}
 	defer resp.Body.Close()
 
 	data, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return errOnTheGraph
+		return nil, &TransportError{Err: err}
 	}
 
-	err = json.Unmarshal(data, &result)
-	if err != nil {
-		return errOnTheGraph
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		return nil, &StatusError{
+			StatusCode: resp.StatusCode,
+			Body:       bodyExcerpt(data),
+			RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After")),
+		}
 	}
 
-	return nil
+	return data, nil
 }
@@ -2,10 +2,13 @@ package thegraph
 
 import (
 	"bytes"
+	"context"
+	"encoding/json"
 	"errors"
 	"io"
 	"net/http"
 	"testing"
+	"time"
 )
 
 // MockRoundTripper to mock HTTP responses
@@ -18,14 +21,20 @@ func (m *MockRoundTripper) RoundTrip(req *http.Request) (*http.Response, error)
 }
 
 // Helper to create a mocked client
-func newMockedClient(fn func(req *http.Request) (*http.Response, error)) *Client {
-	return &Client{
+func newMockedClient(fn func(req *http.Request) (*http.Response, error), opts ...Option) *Client {
+	client := &Client{
 		baseURL: "http://mocked",
 		httpClient: &http.Client{
 			Timeout:   defaulHttpTimeout,
 			Transport: &MockRoundTripper{RoundTripFunc: fn},
 		},
 	}
+
+	for _, opt := range opts {
+		opt(client)
+	}
+
+	return client
 }
 
 func TestNewDefaultClient(t *testing.T) {
@@ -72,7 +81,7 @@ func TestGetVouchersSuccess(t *testing.T) {
 		}, nil
 	})
 
-	vouchers, err := client.GetVouchers()
+	vouchers, err := client.GetVouchers(context.Background(), VoucherFilter{})
 	if err != nil {
 		t.Fatalf("expected no error, got %v", err)
 	}
@@ -86,10 +95,115 @@ func TestGetVouchersSuccess(t *testing.T) {
 	}
 }
 
+func TestQuerySuccess(t *testing.T) {
+	mockResponse := `{"data": {"vouchers": [{"id": "1"}]}}`
+
+	var payload graphQLRequest
+	var requestURL string
+
+	client := newMockedClient(func(req *http.Request) (*http.Response, error) {
+		requestURL = req.URL.String()
+		if err := json.NewDecoder(req.Body).Decode(&payload); err != nil {
+			t.Fatalf("failed to decode request body: %v", err)
+		}
+
+		return &http.Response{
+			StatusCode: 200,
+			Body:       io.NopCloser(bytes.NewBufferString(mockResponse)),
+			Header:     make(http.Header),
+		}, nil
+	})
+
+	variables := map[string]interface{}{"id": "1"}
+
+	data, err := client.Query(context.Background(), "iexec-voucher", "query($id: ID!) { vouchers(where: {id: $id}) { id } }", variables)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if requestURL != "http://mocked/iexec-voucher" {
+		t.Errorf("expected URL 'http://mocked/iexec-voucher', got '%s'", requestURL)
+	}
+
+	if payload.Variables["id"] != "1" {
+		t.Errorf("expected variable id '1', got '%v'", payload.Variables["id"])
+	}
+
+	if string(data) != `{"vouchers": [{"id": "1"}]}` {
+		t.Errorf("unexpected data %s", data)
+	}
+}
+
+func TestQueryInvalidEndpoint(t *testing.T) {
+	client := newMockedClient(func(req *http.Request) (*http.Response, error) {
+		t.Fatal("no request expected for an invalid endpoint")
+		return nil, nil
+	})
+
+	for _, endpoint := range []string{"../other", "http://evil.com/graph", "/iexec-voucher?x=1"} {
+		_, err := client.Query(context.Background(), endpoint, "{ _meta { deployment } }", nil)
+		if !errors.Is(err, errInvalidEndpoint) {
+			t.Errorf("expected errInvalidEndpoint for %q, got %v", endpoint, err)
+		}
+	}
+}
+
+// newBlockingClient returns a client whose requests block until their context is done
+func newBlockingClient(started chan<- struct{}) *Client {
+	return newMockedClient(func(req *http.Request) (*http.Response, error) {
+		started <- struct{}{}
+		<-req.Context().Done()
+
+		return nil, req.Context().Err()
+	})
+}
+
+func TestQueryCancellation(t *testing.T) {
+	started := make(chan struct{}, 1)
+	client := newBlockingClient(started)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errCh := make(chan error, 1)
+
+	go func() {
+		_, err := client.Query(ctx, "/iexec-voucher", "{ vouchers { id } }", nil)
+		errCh <- err
+	}()
+
+	<-started
+	cancel()
+
+	select {
+	case err := <-errCh:
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("expected context.Canceled, got %v", err)
+		}
+
+		if !errors.Is(err, errOnTheGraph) {
+			t.Errorf("expected errOnTheGraph, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("request was not aborted by the context cancellation")
+	}
+}
+
+func TestGetVouchersDeadline(t *testing.T) {
+	started := make(chan struct{}, 1)
+	client := newBlockingClient(started)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	_, err := client.GetVouchers(ctx, VoucherFilter{})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected context.DeadlineExceeded, got %v", err)
+	}
+}
+
 func TestFetchGraphQLDataMarshalError(t *testing.T) {
 	client := NewClient("http://example.com")
 
-	err := client.fetchGraphQLData("/whatever", "", nil)
+	err := client.fetchGraphQLData(context.Background(), "/whatever", "", nil, nil)
 	if !errors.Is(err, errOnTheGraph) {
 		t.Errorf("expected errOnTheGraph, got %v", err)
 	}
@@ -100,7 +214,7 @@ func TestFetchGraphQLDataRequestError(t *testing.T) {
 		return nil, errors.New("network error")
 	})
 
-	err := client.fetchGraphQLData("/whatever", "query", nil)
+	err := client.fetchGraphQLData(context.Background(), "/whatever", "query", nil, nil)
 	if !errors.Is(err, errOnTheGraph) {
 		t.Errorf("expected errOnTheGraph, got %v", err)
 	}
@@ -117,7 +231,7 @@ func TestFetchGraphQLDataReadError(t *testing.T) {
 		}, nil
 	})
 
-	err := client.fetchGraphQLData("/whatever", "query", nil)
+	err := client.fetchGraphQLData(context.Background(), "/whatever", "query", nil, nil)
 	if !errors.Is(err, errOnTheGraph) {
 		t.Errorf("expected errOnTheGraph, got %v", err)
 	}
@@ -132,10 +246,101 @@ func TestFetchGraphQLDataUnmarshalError(t *testing.T) {
 		}, nil
 	})
 
-	err := client.fetchGraphQLData("/whatever", "query", nil)
+	err := client.fetchGraphQLData(context.Background(), "/whatever", "query", nil, nil)
+	if !errors.Is(err, errOnTheGraph) {
+		t.Errorf("expected errOnTheGraph, got %v", err)
+	}
+}
+
+func TestFetchGraphQLDataStatusError(t *testing.T) {
+	client := newMockedClient(func(req *http.Request) (*http.Response, error) {
+		return &http.Response{
+			StatusCode: 502,
+			Body:       io.NopCloser(bytes.NewBufferString("bad gateway")),
+			Header:     make(http.Header),
+		}, nil
+	})
+
+	err := client.fetchGraphQLData(context.Background(), "/whatever", "query", nil, nil)
+	if !errors.Is(err, errOnTheGraph) {
+		t.Errorf("expected errOnTheGraph, got %v", err)
+	}
+
+	var statusErr *StatusError
+	if !errors.As(err, &statusErr) {
+		t.Fatalf("expected StatusError, got %T", err)
+	}
+
+	if statusErr.StatusCode != 502 || statusErr.Body != "bad gateway" {
+		t.Errorf("unexpected status error %+v", statusErr)
+	}
+}
+
+func TestFetchGraphQLDataGraphQLErrors(t *testing.T) {
+	mockResponse := `{
+		"errors": [
+			{
+				"message": "Type Query has no field vouchrs",
+				"locations": [{"line": 1, "column": 3}],
+				"path": ["vouchrs", 0]
+			}
+		]
+	}`
+
+	client := newMockedClient(func(req *http.Request) (*http.Response, error) {
+		return &http.Response{
+			StatusCode: 200,
+			Body:       io.NopCloser(bytes.NewBufferString(mockResponse)),
+			Header:     make(http.Header),
+		}, nil
+	})
+
+	_, err := client.GetVouchers(context.Background(), VoucherFilter{})
 	if !errors.Is(err, errOnTheGraph) {
 		t.Errorf("expected errOnTheGraph, got %v", err)
 	}
+
+	var gqlErrs *GraphQLErrors
+	if !errors.As(err, &gqlErrs) {
+		t.Fatalf("expected GraphQLErrors, got %T", err)
+	}
+
+	if len(gqlErrs.Errors) != 1 || gqlErrs.Errors[0].Locations[0].Column != 3 {
+		t.Errorf("unexpected graphql errors %+v", gqlErrs.Errors)
+	}
+
+	expected := "Type Query has no field vouchrs (line 1, column 3) at vouchrs.0"
+	if gqlErrs.Errors[0].String() != expected {
+		t.Errorf("expected '%s', got '%s'", expected, gqlErrs.Errors[0].String())
+	}
+}
+
+func TestFetchGraphQLDataTypedErrors(t *testing.T) {
+	client := newMockedClient(func(req *http.Request) (*http.Response, error) {
+		return nil, errors.New("network error")
+	})
+
+	err := client.fetchGraphQLData(context.Background(), "/whatever", "query", nil, nil)
+
+	var transportErr *TransportError
+	if !errors.As(err, &transportErr) {
+		t.Errorf("expected TransportError, got %T", err)
+	}
+
+	client = newMockedClient(func(req *http.Request) (*http.Response, error) {
+		return &http.Response{
+			StatusCode: 200,
+			Body:       io.NopCloser(bytes.NewBufferString("invalid json")),
+			Header:     make(http.Header),
+		}, nil
+	})
+
+	err = client.fetchGraphQLData(context.Background(), "/whatever", "query", nil, nil)
+
+	var decodeErr *DecodeError
+	if !errors.As(err, &decodeErr) {
+		t.Errorf("expected DecodeError, got %T", err)
+	}
 }
 
 // brokenReader to simulate io.Reader errors
@@ -0,0 +1,149 @@
+package thegraph
+
+import (
+	"context"
+	"errors"
+	"iter"
+	"strconv"
+	"strings"
+
+	"github.com/shopspring/decimal"
+)
+
+// PocoEndpoint is the path of the iExec PoCo subgraph under the TheGraph URL
+const PocoEndpoint = "/poco-v5"
+
+// Deal statuses derived from the task counters
+const (
+	DealStatusPending         = "PENDING"
+	DealStatusCompleted       = "COMPLETED"
+	DealStatusFailed          = "FAILED"
+	DealStatusPartiallyFailed = "PARTIALLY_FAILED"
+)
+
+var errDealNotFound = errors.New("deal not found")
+
+const dealFields = `
+			id
+			timestamp
+			startTime
+			app {
+				id
+				name
+			}
+			dataset {
+				id
+				name
+			}
+			workerpool {
+				id
+				description
+			}
+			appPrice
+			datasetPrice
+			workerpoolPrice
+			category {
+				id
+				name
+				workClockTimeRef
+			}
+			trust
+			tag
+			botFirst
+			botSize
+			requester {
+				id
+			}
+			beneficiary {
+				id
+			}
+			completedTasksCount
+			claimedTasksCount
+			tasks(orderBy: index, orderDirection: asc, first: 100) {
+				id
+				index
+				status
+				finalDeadline
+			}
+		`
+
+var dealCollection = collection{
+	endpoint:   PocoSubgraph,
+	name:       "deals",
+	filterType: "Deal_filter",
+	fields:     dealFields,
+}
+
+// GetDeal fetches a single deal with its tasks
+func (c *Client) GetDeal(ctx context.Context, id string) (Deal, error) {
+	deal, found, err := fetchByID[Deal](ctx, c, PocoSubgraph, "deal", dealFields, id)
+	if err == nil && !found {
+		err = errDealNotFound
+	}
+
+	return deal, err
+}
+
+// GetDealsPage fetches at most limit deals matching filter with an ID greater than cursor
+func (c *Client) GetDealsPage(ctx context.Context, filter DealFilter, cursor string, limit int) (Page[Deal], error) {
+	return fetchPage[Deal](ctx, c, dealCollection, filter.where(), cursor, limit)
+}
+
+// Deals streams all deals matching filter, walking the pages by ID
+func (c *Client) Deals(ctx context.Context, filter DealFilter) iter.Seq2[Deal, error] {
+	return walk[Deal](ctx, c, dealCollection, filter.where())
+}
+
+// where translates the filter into a GraphQL Deal_filter input
+func (f DealFilter) where() map[string]interface{} {
+	where := map[string]interface{}{}
+
+	if f.Requester != "" {
+		where["requester"] = strings.ToLower(f.Requester)
+	}
+
+	if f.App != "" {
+		where["app"] = strings.ToLower(f.App)
+	}
+
+	if f.Dataset != "" {
+		where["dataset"] = strings.ToLower(f.Dataset)
+	}
+
+	if f.Workerpool != "" {
+		where["workerpool"] = strings.ToLower(f.Workerpool)
+	}
+
+	if !f.After.IsZero() {
+		where["timestamp_gte"] = strconv.FormatInt(f.After.Unix(), 10)
+	}
+
+	if !f.Before.IsZero() {
+		where["timestamp_lt"] = strconv.FormatInt(f.Before.Unix(), 10)
+	}
+
+	return where
+}
+
+// Price returns the price of a single task of the deal
+func (d Deal) Price() decimal.Decimal {
+	return d.AppPrice.Add(d.DatasetPrice.Decimal).Add(d.WorkerpoolPrice.Decimal)
+}
+
+// Status derives the deal status from its completed and claimed tasks
+func (d Deal) Status() string {
+	switch {
+	case d.CompletedTasksCount.Add(d.ClaimedTasksCount.Decimal).LessThan(d.BotSize.Decimal):
+		return DealStatusPending
+	case d.ClaimedTasksCount.IsZero():
+		return DealStatusCompleted
+	case d.CompletedTasksCount.IsZero():
+		return DealStatusFailed
+	default:
+		return DealStatusPartiallyFailed
+	}
+}
+
+func (d Deal) entityID() string {
+	return d.ID
+}
@@ -0,0 +1,145 @@
+package thegraph
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+)
+
+const mockDeal = `{
+	"id": "0xdeal",
+	"timestamp": "1733000000",
+	"app": {"id": "0xapp", "name": "my-app"},
+	"dataset": null,
+	"workerpool": {"id": "0xpool", "description": "prod-pool"},
+	"appPrice": "0.1",
+	"datasetPrice": "0",
+	"workerpoolPrice": "0.2",
+	"category": {"id": "0", "name": "XS", "workClockTimeRef": "300"},
+	"trust": "1",
+	"botFirst": "0",
+	"botSize": "2",
+	"requester": {"id": "0xrequester"},
+	"completedTasksCount": "1",
+	"claimedTasksCount": "0",
+	"tasks": [
+		{"id": "0xtask0", "index": "0", "status": "COMPLETED", "finalDeadline": "1733003000"},
+		{"id": "0xtask1", "index": "1", "status": "ACTIVE", "finalDeadline": "1733003000"}
+	]
+}`
+
+func TestGetDeal(t *testing.T) {
+	var payload graphQLRequest
+	var requestURL string
+
+	client := newMockedClient(func(req *http.Request) (*http.Response, error) {
+		requestURL = req.URL.String()
+		if err := json.NewDecoder(req.Body).Decode(&payload); err != nil {
+			t.Fatalf("failed to decode request body: %v", err)
+		}
+
+		return newStatusResponse(200, `{"data": {"deal": `+mockDeal+`}}`), nil
+	})
+
+	deal, err := client.GetDeal(context.Background(), "0xDEAL")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if requestURL != "http://mocked"+PocoEndpoint {
+		t.Errorf("expected PoCo endpoint, got %s", requestURL)
+	}
+
+	if payload.Variables["id"] != "0xdeal" {
+		t.Errorf("expected id '0xdeal', got %v", payload.Variables["id"])
+	}
+
+	if deal.Price().String() != "0.3" {
+		t.Errorf("expected price 0.3, got %s", deal.Price())
+	}
+
+	if deal.Status() != DealStatusPending {
+		t.Errorf("expected status %s, got %s", DealStatusPending, deal.Status())
+	}
+
+	if deal.Dataset != nil || deal.Category.WorkClockTimeRef.Duration != 5*time.Minute {
+		t.Errorf("unexpected deal %+v", deal)
+	}
+
+	if len(deal.Tasks) != 2 || deal.Tasks[1].Status != "ACTIVE" {
+		t.Errorf("unexpected tasks %+v", deal.Tasks)
+	}
+}
+
+func TestGetDealNotFound(t *testing.T) {
+	client := newMockedClient(func(req *http.Request) (*http.Response, error) {
+		return newStatusResponse(200, `{"data": {"deal": null}}`), nil
+	})
+
+	_, err := client.GetDeal(context.Background(), "0xunknown")
+	if !errors.Is(err, errDealNotFound) {
+		t.Errorf("expected errDealNotFound, got %v", err)
+	}
+}
+
+func TestGetDealsPage(t *testing.T) {
+	var payload graphQLRequest
+
+	client := newMockedClient(func(req *http.Request) (*http.Response, error) {
+		if err := json.NewDecoder(req.Body).Decode(&payload); err != nil {
+			t.Fatalf("failed to decode request body: %v", err)
+		}
+
+		return newStatusResponse(200, `{"data": {"deals": [`+mockDeal+`]}}`), nil
+	})
+
+	filter := DealFilter{
+		Requester: "0xREQUESTER",
+		After:     time.Unix(1000, 0),
+		Before:    time.Unix(2000, 0),
+	}
+
+	page, err := client.GetDealsPage(context.Background(), filter, "", 10)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if len(page.Items) != 1 || page.NextCursor != "" {
+		t.Errorf("expected 1 deal without next cursor, got %d and '%s'", len(page.Items), page.NextCursor)
+	}
+
+	where, _ := payload.Variables["where"].(map[string]interface{})
+	expected := map[string]interface{}{
+		"requester":     "0xrequester",
+		"timestamp_gte": "1000",
+		"timestamp_lt":  "2000",
+	}
+
+	for key, value := range expected {
+		if where[key] != value {
+			t.Errorf("expected %s=%v, got %v", key, value, where[key])
+		}
+	}
+}
+
+func TestDealStatus(t *testing.T) {
+	tests := []struct {
+		completed, claimed, botSize BigInt
+		want                        string
+	}{
+		{completed: NewBigInt(0), claimed: NewBigInt(0), botSize: NewBigInt(1), want: DealStatusPending},
+		{completed: NewBigInt(3), claimed: NewBigInt(0), botSize: NewBigInt(3), want: DealStatusCompleted},
+		{completed: NewBigInt(0), claimed: NewBigInt(2), botSize: NewBigInt(2), want: DealStatusFailed},
+		{completed: NewBigInt(1), claimed: NewBigInt(1), botSize: NewBigInt(2), want: DealStatusPartiallyFailed},
+	}
+
+	for _, tt := range tests {
+		deal := Deal{CompletedTasksCount: tt.completed, ClaimedTasksCount: tt.claimed, BotSize: tt.botSize}
+		if deal.Status() != tt.want {
+			t.Errorf("%+v: expected %s, got %s", tt, tt.want, deal.Status())
+		}
+	}
+}
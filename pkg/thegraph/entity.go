@@ -0,0 +1,116 @@
+package thegraph
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+var (
+	errInvalidEntityQuery = errors.New("invalid entity query")
+	errNoEntity           = errors.New("no entity with this ID")
+)
+
+// EntityQuery selects the entities listed by ListEntities, zero values are left to TheGraph defaults
+type EntityQuery struct {
+	// Where is the where filter input, e.g. {"balance_gt": "0"}
+	Where          map[string]interface{}
+	OrderBy        string
+	OrderDirection string
+	Limit          int
+	Skip           int
+}
+
+// ListEntities fetches a page of the entities of a subgraph described by its schema and returns the raw JSON list
+func (c *Client) ListEntities(ctx context.Context, endpoint string, entity Entity, query EntityQuery) (json.RawMessage, error) {
+	definitions := []string{"$first: Int!"}
+	arguments := []string{"first: $first"}
+	variables := map[string]interface{}{"first": normalizeLimit(query.Limit)}
+
+	if query.Skip < 0 {
+		return nil, fmt.Errorf("%w: negative skip %d", errInvalidEntityQuery, query.Skip)
+	}
+
+	if query.Skip > 0 {
+		definitions = append(definitions, "$skip: Int!")
+		arguments = append(arguments, "skip: $skip")
+		variables["skip"] = query.Skip
+	}
+
+	if len(query.Where) > 0 {
+		if entity.filterType == "" {
+			return nil, fmt.Errorf("%w: %s can not be filtered", errInvalidEntityQuery, entity.Name)
+		}
+
+		definitions = append(definitions, "$where: "+entity.filterType)
+		arguments = append(arguments, "where: $where")
+		variables["where"] = query.Where
+	}
+
+	if query.OrderBy != "" {
+		if !slices.Contains(entity.OrderBy, query.OrderBy) {
+			return nil, fmt.Errorf("%w: %s can not be ordered by %s", errInvalidEntityQuery, entity.Name, query.OrderBy)
+		}
+
+		// Enum values are written in the document, they are checked against the schema above
+		arguments = append(arguments, "orderBy: "+query.OrderBy)
+	}
+
+	switch query.OrderDirection {
+	case "":
+	case "asc", "desc":
+		arguments = append(arguments, "orderDirection: "+query.OrderDirection)
+	default:
+		return nil, fmt.Errorf("%w: order direction must be asc or desc, got %s", errInvalidEntityQuery, query.OrderDirection)
+	}
+
+	document := fmt.Sprintf(`
+	query %s(%s) {
+		%s(%s) {%s}
+	}`, entity.Collection, strings.Join(definitions, ", "), entity.Collection, strings.Join(arguments, ", "), entity.selection())
+
+	var response struct {
+		Data map[string]json.RawMessage `json:"data"`
+	}
+
+	if err := c.fetchGraphQLData(ctx, endpoint, document, variables, &response); err != nil {
+		return nil, err
+	}
+
+	return response.Data[entity.Collection], nil
+}
+
+// GetEntity fetches a single entity of a subgraph described by its schema and returns its raw JSON
+func (c *Client) GetEntity(ctx context.Context, endpoint string, entity Entity, id string) (json.RawMessage, error) {
+	if entity.Single == "" {
+		return nil, fmt.Errorf("%w: %s can not be fetched by ID", errInvalidEntityQuery, entity.Name)
+	}
+
+	data, found, err := fetchByID[json.RawMessage](ctx, c, endpoint, entity.Single, entity.selection(), id)
+	if err == nil && !found {
+		err = fmt.Errorf("%w: %s %s", errNoEntity, entity.Name, id)
+	}
+
+	return data, err
+}
+
+// selection returns the fields of an entity, related entities are selected by ID
+// and lists of related entities are left out
+func (e Entity) selection() string {
+	var sb strings.Builder
+
+	for _, field := range e.Fields {
+		switch {
+		case field.Relation == "":
+			sb.WriteString("\n\t\t\t" + field.Name)
+		case !strings.HasPrefix(field.Type, "["):
+			sb.WriteString("\n\t\t\t" + field.Name + " {\n\t\t\t\tid\n\t\t\t}")
+		}
+	}
+	sb.WriteString("\n\t\t")
+
+	return sb.String()
+}
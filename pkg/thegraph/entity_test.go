@@ -0,0 +1,128 @@
+package thegraph
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func mockVoucherEntity(t *testing.T) Entity {
+	t.Helper()
+
+	var response struct {
+		Data struct {
+			Schema Schema `json:"__schema"`
+		} `json:"data"`
+	}
+
+	if err := json.Unmarshal([]byte(mockSchemaResponse), &response); err != nil {
+		t.Fatal(err)
+	}
+
+	entity, err := response.Data.Schema.Entity("Voucher")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return entity
+}
+
+func TestListEntities(t *testing.T) {
+	var payload graphQLRequest
+
+	client := newMockedClient(func(req *http.Request) (*http.Response, error) {
+		if err := json.NewDecoder(req.Body).Decode(&payload); err != nil {
+			t.Fatalf("failed to decode request body: %v", err)
+		}
+
+		return newStatusResponse(200, `{"data": {"vouchers": [{"id": "0x1"}]}}`), nil
+	})
+
+	data, err := client.ListEntities(context.Background(), VoucherSubgraph, mockVoucherEntity(t), EntityQuery{
+		Where:          map[string]interface{}{"balance_gte": "1"},
+		OrderBy:        "balance",
+		OrderDirection: "desc",
+		Limit:          10,
+		Skip:           20,
+	})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if string(data) != `[{"id": "0x1"}]` {
+		t.Errorf("unexpected data %s", data)
+	}
+
+	expected := []string{
+		"query vouchers($first: Int!, $skip: Int!, $where: Voucher_filter)",
+		"vouchers(first: $first, skip: $skip, where: $where, orderBy: balance, orderDirection: desc)",
+		"owner {",
+		"balance_history",
+	}
+	for _, part := range expected {
+		if !strings.Contains(payload.Query, part) {
+			t.Errorf("expected the query to contain %q, got %s", part, payload.Query)
+		}
+	}
+
+	if payload.Variables["first"] != float64(10) || payload.Variables["skip"] != float64(20) {
+		t.Errorf("unexpected variables %v", payload.Variables)
+	}
+}
+
+func TestListEntitiesInvalid(t *testing.T) {
+	client := newMockedClient(func(req *http.Request) (*http.Response, error) {
+		t.Fatal("no request expected")
+		return nil, nil
+	})
+
+	tests := map[string]EntityQuery{
+		"unknown orderBy":         {OrderBy: "owner"},
+		"invalid order direction": {OrderDirection: "up"},
+		"negative skip":           {Skip: -1},
+	}
+
+	for name, query := range tests {
+		if _, err := client.ListEntities(context.Background(), VoucherSubgraph, mockVoucherEntity(t), query); !errors.Is(err, errInvalidEntityQuery) {
+			t.Errorf("%s: expected errInvalidEntityQuery, got %v", name, err)
+		}
+	}
+}
+
+func TestGetEntity(t *testing.T) {
+	var payload graphQLRequest
+	response := `{"data": {"voucher": {"id": "0xabc", "balance": "1"}}}`
+
+	client := newMockedClient(func(req *http.Request) (*http.Response, error) {
+		if err := json.NewDecoder(req.Body).Decode(&payload); err != nil {
+			t.Fatalf("failed to decode request body: %v", err)
+		}
+
+		return newStatusResponse(200, response), nil
+	})
+
+	data, err := client.GetEntity(context.Background(), VoucherSubgraph, mockVoucherEntity(t), "0xABC")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if string(data) != `{"id": "0xabc", "balance": "1"}` || payload.Variables["id"] != "0xabc" {
+		t.Errorf("unexpected data %s for variables %v", data, payload.Variables)
+	}
+
+	if !strings.Contains(payload.Query, "voucher(id: $id)") {
+		t.Errorf("unexpected query %s", payload.Query)
+	}
+
+	if _, err := client.GetEntity(context.Background(), VoucherSubgraph, mockVoucherEntity(t), "Voucher-1"); err != nil || payload.Variables["id"] != "Voucher-1" {
+		t.Errorf("expected an ID which is not hex to be kept as is, got %v with variables %v", err, payload.Variables)
+	}
+
+	response = `{"data": {"voucher": null}}`
+	if _, err := client.GetEntity(context.Background(), VoucherSubgraph, mockVoucherEntity(t), "0x0"); !errors.Is(err, errNoEntity) {
+		t.Errorf("expected errNoEntity, got %v", err)
+	}
+}
@@ -0,0 +1,131 @@
+package thegraph
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+const maxBodyExcerpt = 512
+
+// TransportError is returned when TheGraph could not be reached or the response could not be read
+type TransportError struct {
+	Err error
+}
+
+func (e *TransportError) Error() string {
+	return fmt.Sprintf("%s: %v", errOnTheGraph, e.Err)
+}
+
+func (e *TransportError) Unwrap() error {
+	return e.Err
+}
+
+func (e *TransportError) Is(target error) bool {
+	return target == errOnTheGraph
+}
+
+// StatusError is returned when TheGraph answers with a non 2xx HTTP status
+type StatusError struct {
+	StatusCode int
+	Body       string
+	RetryAfter time.Duration
+}
+
+func (e *StatusError) Error() string {
+	if e.Body == "" {
+		return fmt.Sprintf("%s: HTTP status %d", errOnTheGraph, e.StatusCode)
+	}
+
+	return fmt.Sprintf("%s: HTTP status %d: %s", errOnTheGraph, e.StatusCode, e.Body)
+}
+
+func (e *StatusError) Is(target error) bool {
+	return target == errOnTheGraph
+}
+
+// GraphQLErrors is returned when the GraphQL response carries an errors array
+type GraphQLErrors struct {
+	Errors []GraphQLError
+}
+
+func (e *GraphQLErrors) Error() string {
+	messages := make([]string, 0, len(e.Errors))
+	for _, gqlErr := range e.Errors {
+		messages = append(messages, gqlErr.String())
+	}
+
+	return fmt.Sprintf("%s: %s", errOnTheGraph, strings.Join(messages, "; "))
+}
+
+func (e *GraphQLErrors) Is(target error) bool {
+	return target == errOnTheGraph
+}
+
+// BlockUnavailableError is returned when the indexer can not serve data for the requested block,
+// either because it was pruned or because it is not indexed yet
+type BlockUnavailableError struct {
+	Block  Block
+	Errors []GraphQLError
+}
+
+func (e *BlockUnavailableError) Error() string {
+	messages := make([]string, 0, len(e.Errors))
+	for _, gqlErr := range e.Errors {
+		messages = append(messages, gqlErr.String())
+	}
+
+	return fmt.Sprintf("%s: block %s is not available on this subgraph, it may have been pruned by the indexer or not be indexed yet: %s",
+		errOnTheGraph, e.Block, strings.Join(messages, "; "))
+}
+
+func (e *BlockUnavailableError) Is(target error) bool {
+	return target == errOnTheGraph
+}
+
+// DecodeError is returned when the response body is not the expected JSON
+type DecodeError struct {
+	Err error
+}
+
+func (e *DecodeError) Error() string {
+	return fmt.Sprintf("%s: invalid response: %v", errOnTheGraph, e.Err)
+}
+
+func (e *DecodeError) Unwrap() error {
+	return e.Err
+}
+
+func (e *DecodeError) Is(target error) bool {
+	return target == errOnTheGraph
+}
+
+// String formats a GraphQL error with its locations and path
+func (e GraphQLError) String() string {
+	var sb strings.Builder
+	sb.WriteString(e.Message)
+
+	for _, location := range e.Locations {
+		fmt.Fprintf(&sb, " (line %d, column %d)", location.Line, location.Column)
+	}
+
+	if len(e.Path) > 0 {
+		path := make([]string, 0, len(e.Path))
+		for _, p := range e.Path {
+			path = append(path, fmt.Sprint(p))
+		}
+		fmt.Fprintf(&sb, " at %s", strings.Join(path, "."))
+	}
+
+	return sb.String()
+}
+
+// bodyExcerpt truncates a response body so it can be embedded in an error message
+func bodyExcerpt(body []byte) string {
+	excerpt := strings.TrimSpace(string(body))
+	if len(excerpt) > maxBodyExcerpt {
+		excerpt = excerpt[:maxBodyExcerpt] + "..."
+	}
+
+	return excerpt
+}
@@ -0,0 +1,629 @@
+package thegraph
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var errInvalidDocument = errors.New("invalid GraphQL document")
+
+type tokenKind int
+
+const (
+	tokenName tokenKind = iota
+	tokenPunctuator
+	tokenString
+	tokenNumber
+)
+
+// token is a lexical token of a GraphQL document, start and end are byte offsets in the document
+type token struct {
+	kind  tokenKind
+	value string
+	start int
+	end   int
+}
+
+func (t token) is(value string) bool {
+	return t.kind == tokenPunctuator && t.value == value
+}
+
+// tokenize splits a GraphQL document into tokens, ignoring whitespace, commas and comments
+func tokenize(document string) ([]token, error) {
+	var tokens []token
+
+	for i := 0; i < len(document); {
+		c := document[i]
+
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == ',':
+			i++
+		case c == '#':
+			for i < len(document) && document[i] != '\n' && document[i] != '\r' {
+				i++
+			}
+		case c == '"':
+			end, err := scanString(document, i)
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, token{kind: tokenString, value: document[i:end], start: i, end: end})
+			i = end
+		case strings.HasPrefix(document[i:], "..."):
+			tokens = append(tokens, token{kind: tokenPunctuator, value: "...", start: i, end: i + 3})
+			i += 3
+		case strings.IndexByte("!$&()[]{}:=@|", c) >= 0:
+			tokens = append(tokens, token{kind: tokenPunctuator, value: string(c), start: i, end: i + 1})
+			i++
+		case isNameStart(c):
+			end := i + 1
+			for end < len(document) && (isNameStart(document[end]) || isDigit(document[end])) {
+				end++
+			}
+			tokens = append(tokens, token{kind: tokenName, value: document[i:end], start: i, end: end})
+			i = end
+		case c == '-' || isDigit(c):
+			end := i + 1
+			for end < len(document) && (isDigit(document[end]) || strings.IndexByte(".eE+-", document[end]) >= 0) {
+				end++
+			}
+			tokens = append(tokens, token{kind: tokenNumber, value: document[i:end], start: i, end: end})
+			i = end
+		default:
+			return nil, fmt.Errorf("%w: unexpected character %q at offset %d", errInvalidDocument, c, i)
+		}
+	}
+
+	return tokens, nil
+}
+
+// scanString returns the end offset of the string or block string starting at start
+func scanString(document string, start int) (int, error) {
+	if strings.HasPrefix(document[start:], `"""`) {
+		end := strings.Index(document[start+3:], `"""`)
+		if end < 0 {
+			return 0, fmt.Errorf("%w: unterminated string at offset %d", errInvalidDocument, start)
+		}
+
+		return start + 3 + end + 3, nil
+	}
+
+	for i := start + 1; i < len(document); i++ {
+		switch document[i] {
+		case '\\':
+			i++
+		case '"':
+			return i + 1, nil
+		case '\n', '\r':
+			return 0, fmt.Errorf("%w: unterminated string at offset %d", errInvalidDocument, start)
+		}
+	}
+
+	return 0, fmt.Errorf("%w: unterminated string at offset %d", errInvalidDocument, start)
+}
+
+func isNameStart(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+// document is a parsed GraphQL document
+type document struct {
+	operations []operation
+	fragments  map[string]fragment
+}
+
+// operation is a query, mutation or subscription of a document
+type operation struct {
+	kind       string
+	name       string
+	variables  []variableDefinition
+	defaults   map[string]value
+	selections []selection
+}
+
+// variableDefinition is a variable declared by an operation, typ is written as in the document, e.g. [ID!]!
+type variableDefinition struct {
+	name       string
+	typ        string
+	hasDefault bool
+}
+
+// fragment is a named fragment definition of a document
+type fragment struct {
+	typeCondition string
+	selections    []selection
+}
+
+// selection is either a field, a fragment spread or an inline fragment
+type selection struct {
+	alias     string
+	name      string
+	arguments map[string]value
+	// nameEnd is the offset just after the field name and argumentsOpen the offset
+	// just after its opening parenthesis, -1 when the field has no argument
+	nameEnd       int
+	argumentsOpen int
+
+	spread        string
+	inline        bool
+	typeCondition string
+
+	selections []selection
+}
+
+type valueKind int
+
+const (
+	valueVariable valueKind = iota
+	valueInt
+	valueFloat
+	valueString
+	valueName
+	valueList
+	valueObject
+)
+
+// value is a GraphQL input value, raw is the variable name or the literal text of scalars
+type value struct {
+	kind   valueKind
+	raw    string
+	list   []value
+	object map[string]value
+}
+
+// maxNesting bounds the nesting of the selection sets, values and types of a document so that
+// the recursive descent can not exhaust the stack
+const maxNesting = 64
+
+// parser is a recursive descent parser of executable GraphQL documents
+type parser struct {
+	tokens []token
+	pos    int
+	depth  int
+}
+
+// parseDocument parses the operations and fragments of a GraphQL document
+func parseDocument(query string) (document, error) {
+	tokens, err := tokenize(query)
+	if err != nil {
+		return document{}, err
+	}
+
+	p := &parser{tokens: tokens}
+	doc := document{fragments: make(map[string]fragment)}
+
+	if len(tokens) == 0 {
+		return doc, fmt.Errorf("%w: empty document", errInvalidDocument)
+	}
+
+	for !p.done() {
+		if p.peekName("fragment") {
+			p.pos++
+			name, err := p.name()
+			if err != nil {
+				return doc, err
+			}
+
+			typeCondition, err := p.typeCondition()
+			if err != nil {
+				return doc, err
+			}
+
+			if err := p.directives(); err != nil {
+				return doc, err
+			}
+
+			selections, err := p.selectionSet()
+			if err != nil {
+				return doc, err
+			}
+
+			doc.fragments[name] = fragment{typeCondition: typeCondition, selections: selections}
+
+			continue
+		}
+
+		op, err := p.operation()
+		if err != nil {
+			return doc, err
+		}
+		doc.operations = append(doc.operations, op)
+	}
+
+	return doc, nil
+}
+
+func (p *parser) done() bool {
+	return p.pos >= len(p.tokens)
+}
+
+func (p *parser) peek(value string) bool {
+	return !p.done() && p.tokens[p.pos].is(value)
+}
+
+func (p *parser) peekName(value string) bool {
+	return !p.done() && p.tokens[p.pos].kind == tokenName && p.tokens[p.pos].value == value
+}
+
+func (p *parser) unexpected() error {
+	if p.done() {
+		return fmt.Errorf("%w: unexpected end of document", errInvalidDocument)
+	}
+
+	t := p.tokens[p.pos]
+
+	return fmt.Errorf("%w: unexpected %q at offset %d", errInvalidDocument, t.value, t.start)
+}
+
+// nest enters a nested selection set, value or type, the caller calls unnest when leaving it
+func (p *parser) nest() error {
+	p.depth++
+	if p.depth > maxNesting {
+		return fmt.Errorf("%w: nested deeper than %d levels", errInvalidDocument, maxNesting)
+	}
+
+	return nil
+}
+
+func (p *parser) unnest() {
+	p.depth--
+}
+
+func (p *parser) expect(value string) (token, error) {
+	if !p.peek(value) {
+		return token{}, p.unexpected()
+	}
+	p.pos++
+
+	return p.tokens[p.pos-1], nil
+}
+
+func (p *parser) name() (string, error) {
+	if p.done() || p.tokens[p.pos].kind != tokenName {
+		return "", p.unexpected()
+	}
+	p.pos++
+
+	return p.tokens[p.pos-1].value, nil
+}
+
+// typeCondition parses "on Type"
+func (p *parser) typeCondition() (string, error) {
+	if !p.peekName("on") {
+		return "", p.unexpected()
+	}
+	p.pos++
+
+	return p.name()
+}
+
+// operation parses an operation, either a shorthand selection set or "query Name($var: Type = default) @directive { ... }"
+func (p *parser) operation() (operation, error) {
+	op := operation{kind: "query", defaults: make(map[string]value)}
+
+	if !p.peek("{") {
+		kind, err := p.name()
+		if err != nil {
+			return op, err
+		}
+		op.kind = kind
+
+		if !p.done() && p.tokens[p.pos].kind == tokenName {
+			op.name = p.tokens[p.pos].value
+			p.pos++
+		}
+
+		if p.peek("(") {
+			variables, err := p.variableDefinitions(op.defaults)
+			if err != nil {
+				return op, err
+			}
+			op.variables = variables
+		}
+
+		if err := p.directives(); err != nil {
+			return op, err
+		}
+	}
+
+	selections, err := p.selectionSet()
+	op.selections = selections
+
+	return op, err
+}
+
+// variableDefinitions parses "($name: Type = default, ...)" and keeps the default values
+func (p *parser) variableDefinitions(defaults map[string]value) ([]variableDefinition, error) {
+	var variables []variableDefinition
+	p.pos++
+
+	for !p.peek(")") {
+		if _, err := p.expect("$"); err != nil {
+			return nil, err
+		}
+
+		name, err := p.name()
+		if err != nil {
+			return nil, err
+		}
+
+		if _, err := p.expect(":"); err != nil {
+			return nil, err
+		}
+
+		start := p.pos
+		if err := p.typeReference(); err != nil {
+			return nil, err
+		}
+
+		var typ strings.Builder
+		for _, t := range p.tokens[start:p.pos] {
+			typ.WriteString(t.value)
+		}
+
+		variable := variableDefinition{name: name, typ: typ.String()}
+
+		if p.peek("=") {
+			p.pos++
+			defaultValue, err := p.value()
+			if err != nil {
+				return nil, err
+			}
+			defaults[name] = defaultValue
+			variable.hasDefault = true
+		}
+
+		if err := p.directives(); err != nil {
+			return nil, err
+		}
+
+		variables = append(variables, variable)
+	}
+	p.pos++
+
+	return variables, nil
+}
+
+// typeReference parses a type such as [Int!]!
+func (p *parser) typeReference() error {
+	if err := p.nest(); err != nil {
+		return err
+	}
+	defer p.unnest()
+
+	if p.peek("[") {
+		p.pos++
+		if err := p.typeReference(); err != nil {
+			return err
+		}
+
+		if _, err := p.expect("]"); err != nil {
+			return err
+		}
+	} else if _, err := p.name(); err != nil {
+		return err
+	}
+
+	if p.peek("!") {
+		p.pos++
+	}
+
+	return nil
+}
+
+func (p *parser) directives() error {
+	for p.peek("@") {
+		p.pos++
+		if _, err := p.name(); err != nil {
+			return err
+		}
+
+		if p.peek("(") {
+			if _, err := p.arguments(); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func (p *parser) arguments() (map[string]value, error) {
+	arguments := make(map[string]value)
+	p.pos++
+
+	for !p.peek(")") {
+		name, err := p.name()
+		if err != nil {
+			return nil, err
+		}
+
+		if _, err := p.expect(":"); err != nil {
+			return nil, err
+		}
+
+		arguments[name], err = p.value()
+		if err != nil {
+			return nil, err
+		}
+	}
+	p.pos++
+
+	return arguments, nil
+}
+
+func (p *parser) value() (value, error) {
+	if err := p.nest(); err != nil {
+		return value{}, err
+	}
+	defer p.unnest()
+
+	if p.done() {
+		return value{}, p.unexpected()
+	}
+
+	t := p.tokens[p.pos]
+
+	switch {
+	case t.is("$"):
+		p.pos++
+		name, err := p.name()
+
+		return value{kind: valueVariable, raw: name}, err
+	case t.is("["):
+		p.pos++
+		list := value{kind: valueList}
+		for !p.peek("]") {
+			item, err := p.value()
+			if err != nil {
+				return value{}, err
+			}
+			list.list = append(list.list, item)
+		}
+		p.pos++
+
+		return list, nil
+	case t.is("{"):
+		p.pos++
+		object := value{kind: valueObject, object: make(map[string]value)}
+		for !p.peek("}") {
+			name, err := p.name()
+			if err != nil {
+				return value{}, err
+			}
+
+			if _, err := p.expect(":"); err != nil {
+				return value{}, err
+			}
+
+			object.object[name], err = p.value()
+			if err != nil {
+				return value{}, err
+			}
+		}
+		p.pos++
+
+		return object, nil
+	case t.kind == tokenNumber:
+		p.pos++
+		if strings.ContainsAny(t.value, ".eE") {
+			return value{kind: valueFloat, raw: t.value}, nil
+		}
+
+		return value{kind: valueInt, raw: t.value}, nil
+	case t.kind == tokenString:
+		p.pos++
+		return value{kind: valueString, raw: t.value}, nil
+	case t.kind == tokenName:
+		p.pos++
+		return value{kind: valueName, raw: t.value}, nil
+	default:
+		return value{}, p.unexpected()
+	}
+}
+
+func (p *parser) selectionSet() ([]selection, error) {
+	if err := p.nest(); err != nil {
+		return nil, err
+	}
+	defer p.unnest()
+
+	if _, err := p.expect("{"); err != nil {
+		return nil, err
+	}
+
+	var selections []selection
+
+	for !p.peek("}") {
+		if p.done() {
+			return nil, p.unexpected()
+		}
+
+		sel, err := p.selection()
+		if err != nil {
+			return nil, err
+		}
+		selections = append(selections, sel)
+	}
+	p.pos++
+
+	if len(selections) == 0 {
+		return nil, fmt.Errorf("%w: empty selection set", errInvalidDocument)
+	}
+
+	return selections, nil
+}
+
+func (p *parser) selection() (selection, error) {
+	sel := selection{argumentsOpen: -1}
+
+	if p.peek("...") {
+		p.pos++
+
+		if !p.done() && p.tokens[p.pos].kind == tokenName && !p.peekName("on") {
+			// ...FragmentName @directives
+			sel.spread = p.tokens[p.pos].value
+			p.pos++
+
+			return sel, p.directives()
+		}
+
+		// ... on Type @directives { ... }
+		sel.inline = true
+		if p.peekName("on") {
+			typeCondition, err := p.typeCondition()
+			if err != nil {
+				return sel, err
+			}
+			sel.typeCondition = typeCondition
+		}
+
+		if err := p.directives(); err != nil {
+			return sel, err
+		}
+
+		selections, err := p.selectionSet()
+		sel.selections = selections
+
+		return sel, err
+	}
+
+	name, err := p.name()
+	if err != nil {
+		return sel, err
+	}
+	sel.name = name
+	sel.nameEnd = p.tokens[p.pos-1].end
+
+	if p.peek(":") {
+		// alias: name
+		p.pos++
+		sel.alias = sel.name
+		if sel.name, err = p.name(); err != nil {
+			return sel, err
+		}
+		sel.nameEnd = p.tokens[p.pos-1].end
+	}
+
+	if p.peek("(") {
+		sel.argumentsOpen = p.tokens[p.pos].end
+		if sel.arguments, err = p.arguments(); err != nil {
+			return sel, err
+		}
+	}
+
+	if err := p.directives(); err != nil {
+		return sel, err
+	}
+
+	if p.peek("{") {
+		sel.selections, err = p.selectionSet()
+	}
+
+	return sel, err
+}
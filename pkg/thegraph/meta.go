@@ -0,0 +1,47 @@
+package thegraph
+
+import (
+	"context"
+	"time"
+)
+
+const metaQuery = `
+	query indexingStatus {
+		_meta {
+			deployment
+			hasIndexingErrors
+			block {
+				number
+				hash
+				timestamp
+			}
+		}
+	}`
+
+// GetMeta fetches the indexing status of a subgraph, given by name or by path
+func (c *Client) GetMeta(ctx context.Context, endpoint string) (Meta, error) {
+	var response struct {
+		Data struct {
+			Meta Meta `json:"_meta"`
+		} `json:"data"`
+	}
+
+	if err := c.fetchGraphQLData(ctx, endpoint, metaQuery, nil, &response); err != nil {
+		return Meta{}, err
+	}
+
+	return response.Data.Meta, nil
+}
+
+// Lag returns how far the indexed block is behind the chain head, in blocks and in time.
+// The lag is zero when the subgraph is ahead of the head, e.g. read from another RPC node, and
+// the time lag is zero when the subgraph does not report its block timestamp
+func (m Meta) Lag(headBlock uint64, headTime time.Time) (int64, time.Duration) {
+	blocks := max(int64(headBlock)-m.Block.Number.IntPart(), 0)
+
+	if m.Block.Timestamp.IsZero() || headTime.IsZero() {
+		return blocks, 0
+	}
+
+	return blocks, max(headTime.Sub(m.Block.Timestamp.Time), 0)
+}
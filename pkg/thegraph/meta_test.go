@@ -0,0 +1,60 @@
+package thegraph
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestGetMeta(t *testing.T) {
+	mockResponse := `{
+		"data": {
+			"_meta": {
+				"deployment": "QmDeployment",
+				"hasIndexingErrors": true,
+				"block": {"number": 1000, "hash": "0xabc", "timestamp": 1733000000}
+			}
+		}
+	}`
+
+	var path string
+
+	client := newMockedClient(func(req *http.Request) (*http.Response, error) {
+		path = req.URL.Path
+		return newStatusResponse(200, mockResponse), nil
+	})
+
+	meta, err := client.GetMeta(context.Background(), "poco-v5")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if path != PocoEndpoint {
+		t.Errorf("expected request on %s, got %s", PocoEndpoint, path)
+	}
+
+	if meta.Deployment != "QmDeployment" || !meta.HasIndexingErrors || meta.Block.Number.IntPart() != 1000 || meta.Block.Hash != "0xabc" {
+		t.Errorf("unexpected meta %+v", meta)
+	}
+
+	blocks, delay := meta.Lag(1012, time.Unix(1733000060, 0))
+	if blocks != 12 || delay != time.Minute {
+		t.Errorf("expected a lag of 12 blocks and 1m, got %d blocks and %s", blocks, delay)
+	}
+
+	// A head read from a node behind the subgraph is not a negative lag
+	blocks, delay = meta.Lag(990, time.Unix(1732999000, 0))
+	if blocks != 0 || delay != 0 {
+		t.Errorf("expected no lag, got %d blocks and %s", blocks, delay)
+	}
+}
+
+func TestMetaLagWithoutTimestamp(t *testing.T) {
+	meta := Meta{Block: MetaBlock{Number: NewBigInt(1000)}}
+
+	blocks, delay := meta.Lag(1000, time.Now())
+	if blocks != 0 || delay != 0 {
+		t.Errorf("expected no lag, got %d blocks and %s", blocks, delay)
+	}
+}
@@ -0,0 +1,86 @@
+package thegraph
+
+import (
+	"net/http"
+	"time"
+)
+
+// Option configures a Client
+type Option func(*Client)
+
+// WithHTTPClient sets the HTTP client used to reach TheGraph, a nil client is http.DefaultClient
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *Client) {
+		if httpClient == nil {
+			httpClient = http.DefaultClient
+		}
+		c.httpClient = httpClient
+	}
+}
+
+// WithTimeout sets the timeout of each HTTP request
+func WithTimeout(timeout time.Duration) Option {
+	return func(c *Client) {
+		httpClient := *c.httpClient
+		httpClient.Timeout = timeout
+		c.httpClient = &httpClient
+	}
+}
+
+// WithTransport sets the HTTP transport used to reach TheGraph
+func WithTransport(transport http.RoundTripper) Option {
+	return func(c *Client) {
+		httpClient := *c.httpClient
+		httpClient.Transport = transport
+		c.httpClient = &httpClient
+	}
+}
+
+// WithHeader adds a header sent with every request
+func WithHeader(key, value string) Option {
+	return func(c *Client) {
+		if c.headers == nil {
+			c.headers = make(http.Header)
+		}
+		c.headers.Set(key, value)
+	}
+}
+
+// WithAPIKey authenticates every request with a bearer API key
+func WithAPIKey(apiKey string) Option {
+	return WithHeader("Authorization", "Bearer "+apiKey)
+}
+
+// WithUserAgent sets the User-Agent sent with every request
+func WithUserAgent(userAgent string) Option {
+	return WithHeader("User-Agent", userAgent)
+}
+
+// WithRetryPolicy sets how failed requests are retried
+func WithRetryPolicy(policy RetryPolicy) Option {
+	return func(c *Client) {
+		c.retryPolicy = policy
+	}
+}
+
+// WithoutRetry disables retries, each request is attempted once
+func WithoutRetry() Option {
+	return func(c *Client) {
+		c.retryPolicy = RetryPolicy{MaxAttempts: 1}
+	}
+}
+
+// WithCircuitBreaker opens the circuit after threshold consecutive failures
+// and fails fast until cooldown has elapsed
+func WithCircuitBreaker(threshold int, cooldown time.Duration) Option {
+	return func(c *Client) {
+		c.breaker = NewCircuitBreaker(threshold, cooldown)
+	}
+}
+
+// WithoutCircuitBreaker disables the circuit breaker
+func WithoutCircuitBreaker() Option {
+	return func(c *Client) {
+		c.breaker = nil
+	}
+}
@@ -0,0 +1,64 @@
+package thegraph
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestNewClientOptions(t *testing.T) {
+	httpClient := &http.Client{}
+	client := NewClient("http://example.com", WithHTTPClient(httpClient), WithTimeout(time.Minute))
+
+	if client.httpClient.Timeout != time.Minute {
+		t.Errorf("expected timeout %s, got %s", time.Minute, client.httpClient.Timeout)
+	}
+
+	if httpClient.Timeout != 0 {
+		t.Errorf("expected the provided HTTP client to be left untouched, got timeout %s", httpClient.Timeout)
+	}
+
+	client = NewClient("http://example.com", WithHTTPClient(nil), WithTimeout(time.Minute))
+	if client.httpClient.Timeout != time.Minute || http.DefaultClient.Timeout != 0 {
+		t.Errorf("expected a copy of the default HTTP client with timeout %s, got %s", time.Minute, client.httpClient.Timeout)
+	}
+
+	client = NewClient("http://example.com", WithoutRetry(), WithoutCircuitBreaker())
+	if client.retryPolicy.MaxAttempts != 1 || client.breaker != nil {
+		t.Errorf("expected retries and circuit breaker to be disabled, got %+v and %v", client.retryPolicy, client.breaker)
+	}
+}
+
+func TestClientSendsHeaders(t *testing.T) {
+	var header http.Header
+
+	transport := &MockRoundTripper{RoundTripFunc: func(req *http.Request) (*http.Response, error) {
+		header = req.Header
+		return newStatusResponse(200, `{"data": {}}`), nil
+	}}
+
+	client := NewClient("http://mocked",
+		WithTransport(transport),
+		WithAPIKey("secret"),
+		WithUserAgent("thegraph-mcp-server/test"),
+		WithHeader("X-Custom", "value"),
+	)
+
+	if _, err := client.Query(context.Background(), "", "{ _meta { deployment } }", nil); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	expected := map[string]string{
+		"Authorization": "Bearer secret",
+		"User-Agent":    "thegraph-mcp-server/test",
+		"X-Custom":      "value",
+		"Content-Type":  "application/json",
+	}
+
+	for key, value := range expected {
+		if header.Get(key) != value {
+			t.Errorf("expected header %s '%s', got '%s'", key, value, header.Get(key))
+		}
+	}
+}
@@ -0,0 +1,388 @@
+package thegraph
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/shopspring/decimal"
+)
+
+// zeroAddress is used by orders to express the absence of restriction
+const zeroAddress = "0x0000000000000000000000000000000000000000"
+
+// maxOrderSkip bounds the price ordered scan looking for the cheapest open order
+const maxOrderSkip = 5000
+
+var errNoOpenOrder = errors.New("no open workerpool order found")
+
+// orderDealsFields selects the first page of deals of an order, used to compute its remaining volume,
+// the next pages are fetched by fetchOrdersDeals
+const orderDealsFields = `
+			deals(first: 1000, orderBy: id) {
+				id
+				botSize
+			}
+		`
+
+const workerpoolOrderFields = `
+			id
+			workerpool {
+				id
+				description
+			}
+			workerpoolprice
+			volume
+			tag
+			category {
+				id
+				name
+			}
+			trust
+			apprestrict
+			datasetrestrict
+			requesterrestrict
+			timestamp` + orderDealsFields
+
+var (
+	appOrderCollection = collection{
+		endpoint:   PocoSubgraph,
+		name:       "appOrders",
+		filterType: "AppOrder_filter",
+		fields: `
+			id
+			app {
+				id
+				name
+			}
+			appprice
+			volume
+			tag
+			datasetrestrict
+			workerpoolrestrict
+			requesterrestrict
+			timestamp` + orderDealsFields,
+	}
+	datasetOrderCollection = collection{
+		endpoint:   PocoSubgraph,
+		name:       "datasetOrders",
+		filterType: "DatasetOrder_filter",
+		fields: `
+			id
+			dataset {
+				id
+				name
+			}
+			datasetprice
+			volume
+			tag
+			apprestrict
+			workerpoolrestrict
+			requesterrestrict
+			timestamp` + orderDealsFields,
+	}
+	workerpoolOrderCollection = collection{
+		endpoint:   PocoSubgraph,
+		name:       "workerpoolOrders",
+		filterType: "WorkerpoolOrder_filter",
+		fields:     workerpoolOrderFields,
+	}
+	requestOrderCollection = collection{
+		endpoint:   PocoSubgraph,
+		name:       "requestOrders",
+		filterType: "RequestOrder_filter",
+		fields: `
+			id
+			app {
+				id
+			}
+			appmaxprice
+			dataset {
+				id
+			}
+			datasetmaxprice
+			workerpool {
+				id
+			}
+			workerpoolmaxprice
+			requester {
+				id
+			}
+			beneficiary {
+				id
+			}
+			volume
+			tag
+			category {
+				id
+				name
+			}
+			trust
+			params
+			timestamp` + orderDealsFields,
+	}
+)
+
+// GetAppOrdersPage fetches at most limit orders of the app with an ID greater than cursor
+func (c *Client) GetAppOrdersPage(ctx context.Context, app, cursor string, limit int) (Page[AppOrder], error) {
+	where := map[string]interface{}{"app": strings.ToLower(app)}
+
+	page, err := fetchPage[AppOrder](ctx, c, appOrderCollection, where, cursor, limit)
+	if err != nil {
+		return page, err
+	}
+
+	return page, fetchOrdersDeals(ctx, c, "appOrder", page.Items, func(o *AppOrder) (string, *[]Deal) { return o.ID, &o.Deals })
+}
+
+// GetDatasetOrdersPage fetches at most limit orders of the dataset with an ID greater than cursor
+func (c *Client) GetDatasetOrdersPage(ctx context.Context, dataset, cursor string, limit int) (Page[DatasetOrder], error) {
+	where := map[string]interface{}{"dataset": strings.ToLower(dataset)}
+
+	page, err := fetchPage[DatasetOrder](ctx, c, datasetOrderCollection, where, cursor, limit)
+	if err != nil {
+		return page, err
+	}
+
+	return page, fetchOrdersDeals(ctx, c, "datasetOrder", page.Items, func(o *DatasetOrder) (string, *[]Deal) { return o.ID, &o.Deals })
+}
+
+// GetWorkerpoolOrdersPage fetches at most limit workerpool orders matching filter with an ID greater than cursor.
+// Fully consumed orders are skipped and more orders are fetched to fill the page, the scan stops after 5000
+// orders and the page may then hold fewer orders while a next one exists
+func (c *Client) GetWorkerpoolOrdersPage(ctx context.Context, filter WorkerpoolOrderFilter, cursor string, limit int) (Page[WorkerpoolOrder], error) {
+	limit = normalizeLimit(limit)
+
+	var page Page[WorkerpoolOrder]
+
+	// The remaining volume depends on the deals, it can not be filtered by the subgraph. The first request
+	// fetches one more order than requested and the next ones, filling a page of consumed orders, fetch more
+	first := min(limit+1, maxPageSize)
+	for scanned := 0; scanned <= maxOrderSkip; scanned, first = scanned+first, maxPageSize {
+		orders, err := fetchEntities[WorkerpoolOrder](ctx, c, workerpoolOrderCollection, filter.where(), cursor, first)
+		if err != nil {
+			return Page[WorkerpoolOrder]{}, err
+		}
+
+		err = fetchOrdersDeals(ctx, c, "workerpoolOrder", orders, func(o *WorkerpoolOrder) (string, *[]Deal) { return o.ID, &o.Deals })
+		if err != nil {
+			return Page[WorkerpoolOrder]{}, err
+		}
+
+		for _, order := range orders {
+			if !order.Remaining().IsPositive() {
+				cursor = order.ID
+				continue
+			}
+
+			if len(page.Items) == limit {
+				// Another open order exists
+				page.NextCursor = cursor
+				return page, nil
+			}
+
+			page.Items = append(page.Items, order)
+			cursor = order.ID
+		}
+
+		if len(orders) < first {
+			return page, nil
+		}
+	}
+
+	page.NextCursor = cursor
+
+	return page, nil
+}
+
+// GetRequestOrdersPage fetches at most limit request orders matching filter with an ID greater than cursor
+func (c *Client) GetRequestOrdersPage(ctx context.Context, filter RequestOrderFilter, cursor string, limit int) (Page[RequestOrder], error) {
+	page, err := fetchPage[RequestOrder](ctx, c, requestOrderCollection, filter.where(), cursor, limit)
+	if err != nil {
+		return page, err
+	}
+
+	return page, fetchOrdersDeals(ctx, c, "requestOrder", page.Items, func(o *RequestOrder) (string, *[]Deal) { return o.ID, &o.Deals })
+}
+
+// CheapestWorkerpoolOrder returns the cheapest workerpool order of the category which still has
+// some volume and is usable by requester, an empty requester only matches unrestricted orders
+func (c *Client) CheapestWorkerpoolOrder(ctx context.Context, category, requester string) (WorkerpoolOrder, error) {
+	query := fmt.Sprintf(`
+	query cheapestWorkerpoolOrders($first: Int!, $skip: Int!, $where: WorkerpoolOrder_filter) {
+		workerpoolOrders(orderBy: workerpoolprice, orderDirection: asc, first: $first, skip: $skip, where: $where) {%s}
+	}`, workerpoolOrderFields)
+
+	where := WorkerpoolOrderFilter{Category: category}.where()
+
+	for skip := 0; skip <= maxOrderSkip; skip += maxPageSize {
+		var response struct {
+			Data struct {
+				WorkerpoolOrders []WorkerpoolOrder `json:"workerpoolOrders"`
+			} `json:"data"`
+		}
+
+		err := c.fetchGraphQLData(ctx, PocoSubgraph, query, map[string]interface{}{
+			"first": maxPageSize,
+			"skip":  skip,
+			"where": where,
+		}, &response)
+		if err != nil {
+			return WorkerpoolOrder{}, err
+		}
+
+		orders := response.Data.WorkerpoolOrders
+		if err := fetchOrdersDeals(ctx, c, "workerpoolOrder", orders, func(o *WorkerpoolOrder) (string, *[]Deal) { return o.ID, &o.Deals }); err != nil {
+			return WorkerpoolOrder{}, err
+		}
+
+		for _, order := range orders {
+			if order.Remaining().IsPositive() && isAllowed(order.RequesterRestrict, requester) {
+				return order, nil
+			}
+		}
+
+		if len(response.Data.WorkerpoolOrders) < maxPageSize {
+			break
+		}
+	}
+
+	return WorkerpoolOrder{}, errNoOpenOrder
+}
+
+// where translates the filter into a GraphQL WorkerpoolOrder_filter input
+func (f WorkerpoolOrderFilter) where() map[string]interface{} {
+	where := map[string]interface{}{}
+
+	if f.Category != "" {
+		where["category"] = f.Category
+	}
+
+	if f.Workerpool != "" {
+		where["workerpool"] = strings.ToLower(f.Workerpool)
+	}
+
+	if f.MaxPrice != nil {
+		where["workerpoolprice_lte"] = f.MaxPrice.String()
+	}
+
+	return where
+}
+
+// where translates the filter into a GraphQL RequestOrder_filter input
+func (f RequestOrderFilter) where() map[string]interface{} {
+	where := map[string]interface{}{}
+
+	if f.Requester != "" {
+		where["requester"] = strings.ToLower(f.Requester)
+	}
+
+	if f.App != "" {
+		where["app"] = strings.ToLower(f.App)
+	}
+
+	if f.Category != "" {
+		where["category"] = f.Category
+	}
+
+	return where
+}
+
+// fetchOrdersDeals fetches the deals of the orders beyond the first page selected with them, name is the
+// entity of the orders, e.g. appOrder, and deals returns the ID and the deals of an order
+func fetchOrdersDeals[T any](ctx context.Context, c *Client, name string, orders []T, deals func(*T) (string, *[]Deal)) error {
+	query := fmt.Sprintf(`
+	query orderDeals($id: ID!, $after: ID!) {
+		%s(id: $id) {
+			deals(first: %d, orderBy: id, where: {id_gt: $after}) {
+				id
+				botSize
+			}
+		}
+	}`, name, maxPageSize)
+
+	for i := range orders {
+		id, orderDeals := deals(&orders[i])
+
+		for next := *orderDeals; len(next) == maxPageSize; {
+			var response struct {
+				Data map[string]*struct {
+					Deals []Deal `json:"deals"`
+				} `json:"data"`
+			}
+
+			err := c.fetchGraphQLData(ctx, PocoSubgraph, query, map[string]interface{}{
+				"id":    id,
+				"after": next[len(next)-1].ID,
+			}, &response)
+			if err != nil {
+				return err
+			}
+
+			next = nil
+			if order := response.Data[name]; order != nil {
+				next = order.Deals
+			}
+			*orderDeals = append(*orderDeals, next...)
+		}
+	}
+
+	return nil
+}
+
+// remainingVolume returns the volume not consumed yet by the deals of an order
+func remainingVolume(volume BigInt, deals []Deal) BigInt {
+	remaining := volume.Decimal
+	for _, deal := range deals {
+		remaining = remaining.Sub(deal.BotSize.Decimal)
+	}
+
+	return BigInt{decimal.Max(remaining, decimal.Zero)}
+}
+
+// IsRestricted reports whether an order restriction targets a specific address
+func IsRestricted(restrict string) bool {
+	return restrict != "" && restrict != zeroAddress
+}
+
+// isAllowed reports whether an order restricted to restrict can be used by account
+func isAllowed(restrict, account string) bool {
+	return !IsRestricted(restrict) || strings.EqualFold(restrict, account)
+}
+
+// Remaining returns the volume of the order not consumed yet
+func (o AppOrder) Remaining() BigInt {
+	return remainingVolume(o.Volume, o.Deals)
+}
+
+// Remaining returns the volume of the order not consumed yet
+func (o DatasetOrder) Remaining() BigInt {
+	return remainingVolume(o.Volume, o.Deals)
+}
+
+// Remaining returns the volume of the order not consumed yet
+func (o WorkerpoolOrder) Remaining() BigInt {
+	return remainingVolume(o.Volume, o.Deals)
+}
+
+// Remaining returns the volume of the order not consumed yet
+func (o RequestOrder) Remaining() BigInt {
+	return remainingVolume(o.Volume, o.Deals)
+}
+
+func (o AppOrder) entityID() string {
+	return o.ID
+}
+
+func (o DatasetOrder) entityID() string {
+	return o.ID
+}
+
+func (o WorkerpoolOrder) entityID() string {
+	return o.ID
+}
+
+func (o RequestOrder) entityID() string {
+	return o.ID
+}
@@ -0,0 +1,172 @@
+package thegraph
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestCheapestWorkerpoolOrder(t *testing.T) {
+	var payload graphQLRequest
+
+	client := newMockedClient(func(req *http.Request) (*http.Response, error) {
+		if err := json.NewDecoder(req.Body).Decode(&payload); err != nil {
+			t.Fatalf("failed to decode request body: %v", err)
+		}
+
+		return newStatusResponse(200, `{"data": {"workerpoolOrders": [
+			{"id": "0xconsumed", "workerpoolprice": "0", "volume": "1", "deals": [{"botSize": "1"}]},
+			{"id": "0xrestricted", "workerpoolprice": "0.1", "volume": "10", "requesterrestrict": "0xsomeoneelse"},
+			{"id": "0xmine", "workerpoolprice": "0.2", "volume": "10", "requesterrestrict": "0xme", "deals": [{"botSize": "3"}]},
+			{"id": "0xopen", "workerpoolprice": "0.3", "volume": "10", "requesterrestrict": "`+zeroAddress+`"}
+		]}}`), nil
+	})
+
+	order, err := client.CheapestWorkerpoolOrder(context.Background(), "0", "")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if order.ID != "0xopen" {
+		t.Errorf("expected unrestricted order '0xopen', got '%s'", order.ID)
+	}
+
+	where, _ := payload.Variables["where"].(map[string]interface{})
+	if where["category"] != "0" {
+		t.Errorf("expected category '0', got %v", where["category"])
+	}
+
+	order, err = client.CheapestWorkerpoolOrder(context.Background(), "0", "0xME")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if order.ID != "0xmine" || order.Remaining().IntPart() != 7 {
+		t.Errorf("expected order '0xmine' with 7 remaining, got '%s' with %s", order.ID, order.Remaining())
+	}
+}
+
+func TestCheapestWorkerpoolOrderNone(t *testing.T) {
+	client := newMockedClient(func(req *http.Request) (*http.Response, error) {
+		return newStatusResponse(200, `{"data": {"workerpoolOrders": []}}`), nil
+	})
+
+	_, err := client.CheapestWorkerpoolOrder(context.Background(), "4", "")
+	if !errors.Is(err, errNoOpenOrder) {
+		t.Errorf("expected errNoOpenOrder, got %v", err)
+	}
+}
+
+func TestGetWorkerpoolOrdersPage(t *testing.T) {
+	var payload graphQLRequest
+
+	client := newMockedClient(func(req *http.Request) (*http.Response, error) {
+		if err := json.NewDecoder(req.Body).Decode(&payload); err != nil {
+			t.Fatalf("failed to decode request body: %v", err)
+		}
+
+		return newStatusResponse(200, `{"data": {"workerpoolOrders": [
+			{"id": "0x1", "workerpoolprice": "0.5", "volume": "2", "deals": [{"botSize": "1"}]},
+			{"id": "0x2", "workerpoolprice": "0.1", "volume": "1", "deals": [{"botSize": "1"}]}
+		]}}`), nil
+	})
+
+	maxPrice := decimal.RequireFromString("1")
+
+	page, err := client.GetWorkerpoolOrdersPage(context.Background(), WorkerpoolOrderFilter{Category: "2", Workerpool: "0xPOOL", MaxPrice: &maxPrice}, "", 0)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if len(page.Items) != 1 || page.Items[0].WorkerpoolPrice.String() != "0.5" {
+		t.Errorf("expected the consumed order to be dropped, got %+v", page.Items)
+	}
+
+	where, _ := payload.Variables["where"].(map[string]interface{})
+	if where["category"] != "2" || where["workerpool"] != "0xpool" || where["workerpoolprice_lte"] != "1" {
+		t.Errorf("unexpected where %v", where)
+	}
+}
+
+func TestGetWorkerpoolOrdersPageSkipsConsumedOrders(t *testing.T) {
+	var requests int
+
+	client := newMockedClient(func(req *http.Request) (*http.Response, error) {
+		var payload graphQLRequest
+		if err := json.NewDecoder(req.Body).Decode(&payload); err != nil {
+			t.Fatalf("failed to decode request body: %v", err)
+		}
+		requests++
+
+		where, _ := payload.Variables["where"].(map[string]interface{})
+		if where["id_gt"] == "0x2" {
+			return newStatusResponse(200, `{"data": {"workerpoolOrders": [
+				{"id": "0x3", "volume": "1"},
+				{"id": "0x4", "volume": "1"}
+			]}}`), nil
+		}
+
+		return newStatusResponse(200, `{"data": {"workerpoolOrders": [
+			{"id": "0x1", "volume": "1", "deals": [{"id": "0xd1", "botSize": "1"}]},
+			{"id": "0x2", "volume": "1", "deals": [{"id": "0xd2", "botSize": "1"}]}
+		]}}`), nil
+	})
+
+	page, err := client.GetWorkerpoolOrdersPage(context.Background(), WorkerpoolOrderFilter{}, "", 1)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if len(page.Items) != 1 || page.Items[0].ID != "0x3" || page.NextCursor != "0x3" || requests != 2 {
+		t.Errorf("expected the page to be filled with 0x3 in 2 requests, got %+v in %d", page, requests)
+	}
+}
+
+func TestOrderDealsPages(t *testing.T) {
+	deals := func(from, count int) string {
+		items := make([]string, count)
+		for i := range items {
+			items[i] = fmt.Sprintf(`{"id": "0x%06d", "botSize": "1"}`, from+i)
+		}
+
+		return strings.Join(items, ",")
+	}
+
+	var after interface{}
+
+	client := newMockedClient(func(req *http.Request) (*http.Response, error) {
+		var payload graphQLRequest
+		if err := json.NewDecoder(req.Body).Decode(&payload); err != nil {
+			t.Fatalf("failed to decode request body: %v", err)
+		}
+
+		if strings.Contains(payload.Query, "query orderDeals(") {
+			after = payload.Variables["after"]
+			return newStatusResponse(200, `{"data": {"requestOrder": {"deals": [`+deals(1000, 500)+`]}}}`), nil
+		}
+
+		return newStatusResponse(200, `{"data": {"requestOrders": [{"id": "0x1", "volume": "2000", "deals": [`+deals(0, 1000)+`]}]}}`), nil
+	})
+
+	page, err := client.GetRequestOrdersPage(context.Background(), RequestOrderFilter{}, "", 10)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if len(page.Items) != 1 || page.Items[0].Remaining().IntPart() != 500 || after != "0x000999" {
+		t.Errorf("expected 500 remaining after deal 0x000999, got %d orders after %v", len(page.Items), after)
+	}
+}
+
+func TestRemainingVolume(t *testing.T) {
+	order := RequestOrder{Volume: NewBigInt(2), Deals: []Deal{{BotSize: NewBigInt(1)}, {BotSize: NewBigInt(5)}}}
+	if !order.Remaining().IsZero() {
+		t.Errorf("expected 0 remaining, got %s", order.Remaining())
+	}
+}
@@ -0,0 +1,130 @@
+package thegraph
+
+import (
+	"context"
+	"fmt"
+	"iter"
+	"strings"
+)
+
+// entity is a subgraph entity which can be paginated by ID
+type entity interface {
+	entityID() string
+}
+
+// collection describes how to query a list of entities from a subgraph
+type collection struct {
+	endpoint   string
+	name       string
+	filterType string
+	fields     string
+}
+
+// query returns the GraphQL document fetching the first entities matching $where, ordered by ID
+func (coll collection) query() string {
+	return fmt.Sprintf(`
+	query %s($first: Int!, $where: %s) {
+		%s(orderBy: id, orderDirection: asc, first: $first, where: $where) {%s}
+	}`, coll.name, coll.filterType, coll.name, coll.fields)
+}
+
+// fetchPage fetches at most limit entities matching where with an ID greater than cursor,
+// NextCursor of the returned page is empty when no more entities remain
+func fetchPage[T entity](ctx context.Context, c *Client, coll collection, where map[string]interface{}, cursor string, limit int) (Page[T], error) {
+	limit = normalizeLimit(limit)
+
+	// Fetch one more entity than requested to know if another page exists,
+	// a full page at the TheGraph maximum is assumed to have a next one
+	first := min(limit+1, maxPageSize)
+
+	items, err := fetchEntities[T](ctx, c, coll, where, cursor, first)
+	if err != nil {
+		return Page[T]{}, err
+	}
+
+	page := Page[T]{Items: items}
+	if len(items) > limit {
+		page.Items = items[:limit]
+	}
+
+	if len(items) == first && len(page.Items) > 0 {
+		page.NextCursor = page.Items[len(page.Items)-1].entityID()
+	}
+
+	return page, nil
+}
+
+// walk streams all entities matching where, walking the pages by ID
+func walk[T entity](ctx context.Context, c *Client, coll collection, where map[string]interface{}) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		cursor := ""
+
+		for {
+			items, err := fetchEntities[T](ctx, c, coll, where, cursor, maxPageSize)
+			if err != nil {
+				var zero T
+				yield(zero, err)
+
+				return
+			}
+
+			for _, item := range items {
+				if !yield(item, nil) {
+					return
+				}
+			}
+
+			if len(items) < maxPageSize {
+				return
+			}
+			cursor = items[len(items)-1].entityID()
+		}
+	}
+}
+
+// fetchEntities fetches the first entities matching where with an ID greater than cursor
+func fetchEntities[T entity](ctx context.Context, c *Client, coll collection, where map[string]interface{}, cursor string, first int) ([]T, error) {
+	conditions := make(map[string]interface{}, len(where)+1)
+	for key, value := range where {
+		conditions[key] = value
+	}
+
+	if cursor != "" {
+		conditions["id_gt"] = cursor
+	}
+
+	var response struct {
+		Data map[string][]T `json:"data"`
+	}
+
+	err := c.fetchGraphQLData(ctx, coll.endpoint, coll.query(), map[string]interface{}{
+		"first": first,
+		"where": conditions,
+	}, &response)
+
+	return response.Data[coll.name], err
+}
+
+// fetchByID fetches a single entity by ID, found is false when the subgraph does not know it
+func fetchByID[T any](ctx context.Context, c *Client, endpoint, name, fields, id string) (item T, found bool, err error) {
+	query := fmt.Sprintf(`
+	query %s($id: ID!) {
+		%s(id: $id) {%s}
+	}`, name, name, fields)
+
+	var response struct {
+		Data map[string]*T `json:"data"`
+	}
+
+	// Addresses and hashes are stored lower cased, the other IDs are case sensitive
+	if strings.HasPrefix(id, "0x") {
+		id = strings.ToLower(id)
+	}
+
+	err = c.fetchGraphQLData(ctx, endpoint, query, map[string]interface{}{"id": id}, &response)
+	if err != nil || response.Data[name] == nil {
+		return item, false, err
+	}
+
+	return *response.Data[name], true, nil
+}
@@ -0,0 +1,140 @@
+package thegraph
+
+import (
+	"context"
+	"errors"
+	"strings"
+)
+
+var (
+	errAppNotFound        = errors.New("app not found")
+	errDatasetNotFound    = errors.New("dataset not found")
+	errWorkerpoolNotFound = errors.New("workerpool not found")
+)
+
+const (
+	appFields = `
+			id
+			owner {
+				id
+			}
+			name
+			multiaddr
+			checksum
+			mrenclave
+			timestamp
+		`
+	datasetFields = `
+			id
+			owner {
+				id
+			}
+			name
+			multiaddr
+			checksum
+			timestamp
+		`
+	workerpoolFields = `
+			id
+			owner {
+				id
+			}
+			description
+			workerStakeRatio
+			schedulerRewardRatio
+			timestamp
+		`
+)
+
+var (
+	appCollection = collection{
+		endpoint:   PocoSubgraph,
+		name:       "apps",
+		filterType: "App_filter",
+		fields:     appFields,
+	}
+	datasetCollection = collection{
+		endpoint:   PocoSubgraph,
+		name:       "datasets",
+		filterType: "Dataset_filter",
+		fields:     datasetFields,
+	}
+	workerpoolCollection = collection{
+		endpoint:   PocoSubgraph,
+		name:       "workerpools",
+		filterType: "Workerpool_filter",
+		fields:     workerpoolFields,
+	}
+)
+
+// GetApp fetches a single app from the registry
+func (c *Client) GetApp(ctx context.Context, id string) (App, error) {
+	app, found, err := fetchByID[App](ctx, c, PocoSubgraph, "app", appFields, id)
+	if err == nil && !found {
+		err = errAppNotFound
+	}
+
+	return app, err
+}
+
+// GetAppsPage fetches at most limit apps matching filter with an ID greater than cursor
+func (c *Client) GetAppsPage(ctx context.Context, filter AssetFilter, cursor string, limit int) (Page[App], error) {
+	return fetchPage[App](ctx, c, appCollection, filter.where("name"), cursor, limit)
+}
+
+// GetDataset fetches a single dataset from the registry
+func (c *Client) GetDataset(ctx context.Context, id string) (Dataset, error) {
+	dataset, found, err := fetchByID[Dataset](ctx, c, PocoSubgraph, "dataset", datasetFields, id)
+	if err == nil && !found {
+		err = errDatasetNotFound
+	}
+
+	return dataset, err
+}
+
+// GetDatasetsPage fetches at most limit datasets matching filter with an ID greater than cursor
+func (c *Client) GetDatasetsPage(ctx context.Context, filter AssetFilter, cursor string, limit int) (Page[Dataset], error) {
+	return fetchPage[Dataset](ctx, c, datasetCollection, filter.where("name"), cursor, limit)
+}
+
+// GetWorkerpool fetches a single workerpool from the registry
+func (c *Client) GetWorkerpool(ctx context.Context, id string) (Workerpool, error) {
+	workerpool, found, err := fetchByID[Workerpool](ctx, c, PocoSubgraph, "workerpool", workerpoolFields, id)
+	if err == nil && !found {
+		err = errWorkerpoolNotFound
+	}
+
+	return workerpool, err
+}
+
+// GetWorkerpoolsPage fetches at most limit workerpools matching filter with an ID greater than cursor
+func (c *Client) GetWorkerpoolsPage(ctx context.Context, filter AssetFilter, cursor string, limit int) (Page[Workerpool], error) {
+	return fetchPage[Workerpool](ctx, c, workerpoolCollection, filter.where("description"), cursor, limit)
+}
+
+// where translates the filter into a GraphQL filter input, nameField is the field matched by Name
+func (f AssetFilter) where(nameField string) map[string]interface{} {
+	where := map[string]interface{}{}
+
+	if f.Owner != "" {
+		where["owner"] = strings.ToLower(f.Owner)
+	}
+
+	if f.Name != "" {
+		where[nameField+"_contains_nocase"] = f.Name
+	}
+
+	return where
+}
+
+func (a App) entityID() string {
+	return a.ID
+}
+
+func (d Dataset) entityID() string {
+	return d.ID
+}
+
+func (w Workerpool) entityID() string {
+	return w.ID
+}
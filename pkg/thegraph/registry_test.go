@@ -0,0 +1,102 @@
+package thegraph
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+)
+
+func TestGetApp(t *testing.T) {
+	multiaddr := "0x" + hex.EncodeToString([]byte("docker.io/iexechub/hello-world:1.0.0"))
+	mockResponse := `{
+		"data": {
+			"app": {
+				"id": "0xapp",
+				"owner": {"id": "0xowner"},
+				"name": "hello-world",
+				"multiaddr": "` + multiaddr + `",
+				"checksum": "0x1234",
+				"mrenclave": "0x",
+				"timestamp": "1700000000"
+			}
+		}
+	}`
+
+	client := newMockedClient(func(req *http.Request) (*http.Response, error) {
+		return newStatusResponse(200, mockResponse), nil
+	})
+
+	app, err := client.GetApp(context.Background(), "0xAPP")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if app.Name != "hello-world" || app.Owner.ID != "0xowner" {
+		t.Errorf("unexpected app %+v", app)
+	}
+
+	if app.Multiaddr.Text() != "docker.io/iexechub/hello-world:1.0.0" {
+		t.Errorf("unexpected multiaddr %s", app.Multiaddr.Text())
+	}
+}
+
+func TestGetRegistryNotFound(t *testing.T) {
+	client := newMockedClient(func(req *http.Request) (*http.Response, error) {
+		return newStatusResponse(200, `{"data": {"app": null, "dataset": null, "workerpool": null}}`), nil
+	})
+
+	if _, err := client.GetApp(context.Background(), "0x1"); !errors.Is(err, errAppNotFound) {
+		t.Errorf("expected errAppNotFound, got %v", err)
+	}
+
+	if _, err := client.GetDataset(context.Background(), "0x1"); !errors.Is(err, errDatasetNotFound) {
+		t.Errorf("expected errDatasetNotFound, got %v", err)
+	}
+
+	if _, err := client.GetWorkerpool(context.Background(), "0x1"); !errors.Is(err, errWorkerpoolNotFound) {
+		t.Errorf("expected errWorkerpoolNotFound, got %v", err)
+	}
+}
+
+func TestGetWorkerpoolsPage(t *testing.T) {
+	var payload graphQLRequest
+
+	client := newMockedClient(func(req *http.Request) (*http.Response, error) {
+		if err := json.NewDecoder(req.Body).Decode(&payload); err != nil {
+			t.Fatalf("failed to decode request body: %v", err)
+		}
+
+		return newStatusResponse(200, `{"data": {"workerpools": [
+			{"id": "0x1", "description": "prod-v8-bellecour", "workerStakeRatio": "35"},
+			{"id": "0x2", "description": "prod-v8-learn"}
+		]}}`), nil
+	})
+
+	page, err := client.GetWorkerpoolsPage(context.Background(), AssetFilter{Owner: "0xOWNER", Name: "prod"}, "", 1)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if len(page.Items) != 1 || page.NextCursor != "0x1" {
+		t.Errorf("expected 1 workerpool with next cursor '0x1', got %d and '%s'", len(page.Items), page.NextCursor)
+	}
+
+	if page.Items[0].WorkerStakeRatio.IntPart() != 35 {
+		t.Errorf("unexpected workerpool %+v", page.Items[0])
+	}
+
+	where, _ := payload.Variables["where"].(map[string]interface{})
+	if where["owner"] != "0xowner" || where["description_contains_nocase"] != "prod" {
+		t.Errorf("unexpected where %v", where)
+	}
+}
+
+func TestAssetFilterWhere(t *testing.T) {
+	where := AssetFilter{Name: "hello"}.where("name")
+	if len(where) != 1 || where["name_contains_nocase"] != "hello" {
+		t.Errorf("unexpected where %v", where)
+	}
+}
@@ -0,0 +1,191 @@
+package thegraph
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	defaultBreakerThreshold = 5
+	defaultBreakerCooldown  = 30 * time.Second
+)
+
+var errCircuitOpen = fmt.Errorf("%w: circuit breaker open, TheGraph looks unavailable", errOnTheGraph)
+
+// DefaultRetryPolicy is the retry policy used by NewClient
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts:    3,
+	InitialBackoff: 250 * time.Millisecond,
+	MaxBackoff:     5 * time.Second,
+}
+
+// RetryPolicy defines how requests failing on network errors, 429 or 5xx are retried
+type RetryPolicy struct {
+	// MaxAttempts is the total number of attempts, including the first one
+	MaxAttempts int
+	// InitialBackoff is the delay before the first retry, doubled on each retry
+	InitialBackoff time.Duration
+	// MaxBackoff caps the delay between two attempts, a longer Retry-After stops retrying
+	MaxBackoff time.Duration
+}
+
+// next returns the delay before the next attempt and whether the request should be retried
+func (p RetryPolicy) next(attempt int, err error) (time.Duration, bool) {
+	if attempt >= p.MaxAttempts || !isRetryable(err) {
+		return 0, false
+	}
+
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) && statusErr.RetryAfter > 0 {
+		return statusErr.RetryAfter, statusErr.RetryAfter <= p.MaxBackoff
+	}
+
+	backoff := p.InitialBackoff << (attempt - 1)
+	if backoff <= 0 || backoff > p.MaxBackoff {
+		backoff = p.MaxBackoff
+	}
+
+	if backoff <= 0 {
+		return 0, true
+	}
+
+	// Equal jitter: wait between half and the full backoff
+	half := backoff >> 1
+	jitter := time.Duration(rand.Int64N(int64(backoff-half) + 1)) //nolint:gosec // jitter does not need a secure source
+
+	return half + jitter, true
+}
+
+// isRetryable reports whether err is a transient failure of TheGraph
+func isRetryable(err error) bool {
+	var transportErr *TransportError
+	if errors.As(err, &transportErr) {
+		return true
+	}
+
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.StatusCode == http.StatusTooManyRequests || statusErr.StatusCode >= http.StatusInternalServerError
+	}
+
+	return false
+}
+
+// parseRetryAfter reads a Retry-After header given in seconds or as an HTTP date
+func parseRetryAfter(value string) time.Duration {
+	if value == "" {
+		return 0
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+
+	if date, err := http.ParseTime(value); err == nil {
+		return max(time.Until(date), 0)
+	}
+
+	return 0
+}
+
+// sleepContext waits for delay or until ctx is done
+func sleepContext(ctx context.Context, delay time.Duration) error {
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// CircuitBreaker stops sending requests to a subgraph URL after consecutive failures, each URL has its
+// own circuit so that an unavailable graph-node does not block the others
+type CircuitBreaker struct {
+	mu        sync.Mutex
+	threshold int
+	cooldown  time.Duration
+	circuits  map[string]*circuit
+	now       func() time.Time
+}
+
+// circuit is the state of the breaker for a single URL
+type circuit struct {
+	failures  int
+	openUntil time.Time
+	// probing is set while the single request allowed by the half-open circuit is in flight
+	probing bool
+}
+
+// NewCircuitBreaker creates a circuit breaker opening after threshold consecutive failures for cooldown
+func NewCircuitBreaker(threshold int, cooldown time.Duration) *CircuitBreaker {
+	return &CircuitBreaker{
+		threshold: threshold,
+		cooldown:  cooldown,
+		circuits:  make(map[string]*circuit),
+		now:       time.Now,
+	}
+}
+
+// allow reports whether a request may be sent to url, once the cooldown has elapsed the circuit is half-open:
+// a single probe request is allowed until its outcome is recorded and a failure opens the circuit again
+func (b *CircuitBreaker) allow(url string) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	circuit, found := b.circuits[url]
+	if !found || circuit.failures < b.threshold {
+		return true
+	}
+
+	if circuit.probing || b.now().Before(circuit.openUntil) {
+		return false
+	}
+
+	circuit.probing = true
+
+	return true
+}
+
+// abandon releases the probe of a half-open circuit whose request was aborted by the caller, the outcome
+// of the request says nothing about the health of url
+func (b *CircuitBreaker) abandon(url string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if circuit, found := b.circuits[url]; found {
+		circuit.probing = false
+	}
+}
+
+// record updates the circuit of url with the outcome of a request, only transient failures are counted
+func (b *CircuitBreaker) record(url string, err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if err == nil || !isRetryable(err) {
+		delete(b.circuits, url)
+
+		return
+	}
+
+	state, found := b.circuits[url]
+	if !found {
+		state = &circuit{}
+		b.circuits[url] = state
+	}
+
+	state.failures++
+	state.probing = false
+	if state.failures >= b.threshold {
+		state.openUntil = b.now().Add(b.cooldown)
+	}
+}
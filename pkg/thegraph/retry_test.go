@@ -0,0 +1,238 @@
+package thegraph
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+)
+
+var testRetryPolicy = RetryPolicy{
+	MaxAttempts:    3,
+	InitialBackoff: time.Millisecond,
+	MaxBackoff:     5 * time.Millisecond,
+}
+
+// newStatusResponse builds a mocked response with the given status and body
+func newStatusResponse(status int, body string) *http.Response {
+	return &http.Response{
+		StatusCode: status,
+		Body:       io.NopCloser(bytes.NewBufferString(body)),
+		Header:     make(http.Header),
+	}
+}
+
+func TestRetryOnServerErrorThenSuccess(t *testing.T) {
+	calls := 0
+
+	client := newMockedClient(func(req *http.Request) (*http.Response, error) {
+		calls++
+		if calls < 3 {
+			return newStatusResponse(503, "unavailable"), nil
+		}
+
+		return newStatusResponse(200, `{"data": {}}`), nil
+	}, WithRetryPolicy(testRetryPolicy))
+
+	_, err := client.Query(context.Background(), "", "{ _meta { deployment } }", nil)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if calls != 3 {
+		t.Errorf("expected 3 attempts, got %d", calls)
+	}
+}
+
+func TestRetryOnNetworkError(t *testing.T) {
+	calls := 0
+
+	client := newMockedClient(func(req *http.Request) (*http.Response, error) {
+		calls++
+		if calls == 1 {
+			return nil, errors.New("connection reset")
+		}
+
+		return newStatusResponse(200, `{"data": {}}`), nil
+	}, WithRetryPolicy(testRetryPolicy))
+
+	_, err := client.Query(context.Background(), "", "{ _meta { deployment } }", nil)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if calls != 2 {
+		t.Errorf("expected 2 attempts, got %d", calls)
+	}
+}
+
+func TestRetryStopsAtMaxAttempts(t *testing.T) {
+	calls := 0
+
+	client := newMockedClient(func(req *http.Request) (*http.Response, error) {
+		calls++
+		return newStatusResponse(500, "boom"), nil
+	}, WithRetryPolicy(testRetryPolicy))
+
+	_, err := client.Query(context.Background(), "", "{ _meta { deployment } }", nil)
+
+	var statusErr *StatusError
+	if !errors.As(err, &statusErr) || statusErr.StatusCode != 500 {
+		t.Errorf("expected StatusError 500, got %v", err)
+	}
+
+	if calls != testRetryPolicy.MaxAttempts {
+		t.Errorf("expected %d attempts, got %d", testRetryPolicy.MaxAttempts, calls)
+	}
+}
+
+func TestNoRetryOnClientError(t *testing.T) {
+	calls := 0
+
+	client := newMockedClient(func(req *http.Request) (*http.Response, error) {
+		calls++
+		return newStatusResponse(400, "bad request"), nil
+	}, WithRetryPolicy(testRetryPolicy))
+
+	_, err := client.Query(context.Background(), "", "{ _meta { deployment } }", nil)
+	if !errors.Is(err, errOnTheGraph) {
+		t.Errorf("expected errOnTheGraph, got %v", err)
+	}
+
+	if calls != 1 {
+		t.Errorf("expected 1 attempt, got %d", calls)
+	}
+}
+
+func TestRetryAfterHonored(t *testing.T) {
+	calls := 0
+
+	client := newMockedClient(func(req *http.Request) (*http.Response, error) {
+		calls++
+
+		resp := newStatusResponse(429, "slow down")
+		resp.Header.Set("Retry-After", "60")
+
+		return resp, nil
+	}, WithRetryPolicy(testRetryPolicy))
+
+	_, err := client.Query(context.Background(), "", "{ _meta { deployment } }", nil)
+
+	var statusErr *StatusError
+	if !errors.As(err, &statusErr) || statusErr.RetryAfter != time.Minute {
+		t.Fatalf("expected StatusError with a one minute Retry-After, got %v", err)
+	}
+
+	if calls != 1 {
+		t.Errorf("expected no retry beyond the max backoff, got %d attempts", calls)
+	}
+
+	delay, retry := RetryPolicy{MaxAttempts: 2, MaxBackoff: time.Minute}.next(1, statusErr)
+	if !retry || delay != time.Minute {
+		t.Errorf("expected a retry after one minute, got %s (retry=%t)", delay, retry)
+	}
+}
+
+func TestRetryBackoffBounds(t *testing.T) {
+	policy := RetryPolicy{MaxAttempts: 10, InitialBackoff: 100 * time.Millisecond, MaxBackoff: time.Second}
+	err := &TransportError{Err: errors.New("timeout")}
+
+	for attempt := 1; attempt < policy.MaxAttempts; attempt++ {
+		delay, retry := policy.next(attempt, err)
+		if !retry {
+			t.Fatalf("expected a retry on attempt %d", attempt)
+		}
+
+		backoff := min(policy.InitialBackoff<<(attempt-1), policy.MaxBackoff)
+		if delay < backoff/2 || delay > backoff {
+			t.Errorf("attempt %d: delay %s out of [%s, %s]", attempt, delay, backoff/2, backoff)
+		}
+	}
+
+	if _, retry := policy.next(policy.MaxAttempts, err); retry {
+		t.Error("expected no retry after the last attempt")
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	if d := parseRetryAfter("3"); d != 3*time.Second {
+		t.Errorf("expected 3s, got %s", d)
+	}
+
+	if d := parseRetryAfter(time.Now().Add(time.Hour).UTC().Format(http.TimeFormat)); d < 59*time.Minute {
+		t.Errorf("expected about one hour, got %s", d)
+	}
+
+	if d := parseRetryAfter("soon"); d != 0 {
+		t.Errorf("expected 0, got %s", d)
+	}
+}
+
+func TestCircuitBreakerFailsFast(t *testing.T) {
+	calls := 0
+
+	client := newMockedClient(func(req *http.Request) (*http.Response, error) {
+		calls++
+		return newStatusResponse(502, "bad gateway"), nil
+	}, WithCircuitBreaker(2, time.Hour))
+
+	for range 2 {
+		if _, err := client.Query(context.Background(), "", "{ _meta { deployment } }", nil); err == nil {
+			t.Fatal("expected an error")
+		}
+	}
+
+	_, err := client.Query(context.Background(), "", "{ _meta { deployment } }", nil)
+	if !errors.Is(err, errCircuitOpen) || !errors.Is(err, errOnTheGraph) {
+		t.Errorf("expected errCircuitOpen, got %v", err)
+	}
+
+	if calls != 2 {
+		t.Errorf("expected 2 requests before the circuit opened, got %d", calls)
+	}
+}
+
+func TestCircuitBreakerHalfOpen(t *testing.T) {
+	now := time.Now()
+	breaker := NewCircuitBreaker(1, time.Minute)
+	breaker.now = func() time.Time { return now }
+	url := "http://example.com/sub"
+
+	breaker.record(url, &TransportError{Err: errors.New("down")})
+	if breaker.allow(url) {
+		t.Fatal("expected the circuit to be open")
+	}
+
+	if !breaker.allow("http://example.com/other") {
+		t.Fatal("expected the circuit of another URL to stay closed")
+	}
+
+	now = now.Add(time.Minute)
+	if !breaker.allow(url) {
+		t.Fatal("expected the circuit to be half-open after the cooldown")
+	}
+
+	if breaker.allow(url) {
+		t.Fatal("expected a single probe while the circuit is half-open")
+	}
+
+	breaker.abandon(url)
+	if !breaker.allow(url) {
+		t.Fatal("expected an aborted probe to allow another one")
+	}
+
+	breaker.record(url, &StatusError{StatusCode: 503})
+	if breaker.allow(url) {
+		t.Fatal("expected a failure to open the half-open circuit again")
+	}
+
+	now = now.Add(time.Minute)
+	breaker.record(url, nil)
+	breaker.record(url, &StatusError{StatusCode: 400})
+	if !breaker.allow(url) {
+		t.Error("expected client errors not to open the circuit")
+	}
+}
@@ -0,0 +1,165 @@
+package thegraph
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/shopspring/decimal"
+)
+
+// RLCDecimals is the number of decimals of the RLC token
+const RLCDecimals = 9
+
+var jsonNull = []byte("null")
+
+// Timestamp is a Unix time in seconds, encoded by TheGraph as a BigInt string
+type Timestamp struct {
+	time.Time
+}
+
+// UnmarshalJSON decodes a Unix time given as a string or a number
+func (t *Timestamp) UnmarshalJSON(data []byte) error {
+	if bytes.Equal(data, jsonNull) {
+		t.Time = time.Time{}
+		return nil
+	}
+
+	raw := string(bytes.Trim(data, `"`))
+
+	seconds, err := strconv.ParseInt(raw, 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid timestamp %s: %w", data, err)
+	}
+
+	t.Time = time.Unix(seconds, 0)
+
+	return nil
+}
+
+// MarshalJSON encodes the Unix time as a string, as TheGraph does
+func (t Timestamp) MarshalJSON() ([]byte, error) {
+	if t.IsZero() {
+		return jsonNull, nil
+	}
+
+	return json.Marshal(strconv.FormatInt(t.Unix(), 10))
+}
+
+// BigInt is an integer encoded by TheGraph as a BigInt string, it is not bounded as order volumes
+// may be set to the uint256 maximum
+type BigInt struct {
+	decimal.Decimal
+}
+
+// NewBigInt returns the BigInt of value
+func NewBigInt(value int64) BigInt {
+	return BigInt{decimal.NewFromInt(value)}
+}
+
+// UnmarshalJSON decodes an integer given as a string or a number
+func (i *BigInt) UnmarshalJSON(data []byte) error {
+	if bytes.Equal(data, jsonNull) {
+		i.Decimal = decimal.Zero
+		return nil
+	}
+
+	value, ok := new(big.Int).SetString(string(bytes.Trim(data, `"`)), 10)
+	if !ok {
+		return fmt.Errorf("invalid integer %s", data)
+	}
+
+	i.Decimal = decimal.NewFromBigInt(value, 0)
+
+	return nil
+}
+
+// MarshalJSON encodes the integer as a string, as TheGraph does
+func (i BigInt) MarshalJSON() ([]byte, error) {
+	return json.Marshal(i.String())
+}
+
+// Bytes is a hex encoded byte string, often holding text such as a docker image or an URL
+type Bytes string
+
+// Text returns the decoded bytes when they are printable text, the raw hex string otherwise
+func (b Bytes) Text() string {
+	data, err := hex.DecodeString(strings.TrimPrefix(string(b), "0x"))
+	if err != nil || len(data) == 0 || !utf8.Valid(data) {
+		return string(b)
+	}
+
+	for _, r := range string(data) {
+		if !unicode.IsPrint(r) {
+			return string(b)
+		}
+	}
+
+	return string(data)
+}
+
+// Seconds is a duration in seconds, encoded by TheGraph as a BigInt string
+type Seconds struct {
+	time.Duration
+}
+
+// UnmarshalJSON decodes a number of seconds given as a string or a number
+func (s *Seconds) UnmarshalJSON(data []byte) error {
+	if bytes.Equal(data, jsonNull) {
+		s.Duration = 0
+		return nil
+	}
+
+	seconds, err := strconv.ParseInt(string(bytes.Trim(data, `"`)), 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid duration %s: %w", data, err)
+	}
+
+	s.Duration = time.Duration(seconds) * time.Second
+
+	return nil
+}
+
+// MarshalJSON encodes the number of seconds as a string, as TheGraph does
+func (s Seconds) MarshalJSON() ([]byte, error) {
+	return json.Marshal(strconv.FormatInt(int64(s.Seconds()), 10))
+}
+
+// Amount is an exact RLC amount, encoded by TheGraph as a BigDecimal string
+type Amount struct {
+	decimal.Decimal
+}
+
+// UnmarshalJSON decodes an amount given as a string or a number
+func (a *Amount) UnmarshalJSON(data []byte) error {
+	if bytes.Equal(data, jsonNull) {
+		a.Decimal = decimal.Zero
+		return nil
+	}
+
+	value, err := decimal.NewFromString(string(bytes.Trim(data, `"`)))
+	if err != nil {
+		return fmt.Errorf("invalid amount %s: %w", data, err)
+	}
+
+	a.Decimal = value
+
+	return nil
+}
+
+// MarshalJSON encodes the amount as a string, as TheGraph does
+func (a Amount) MarshalJSON() ([]byte, error) {
+	return json.Marshal(a.String())
+}
+
+// NRLC returns the amount in nRLC, the smallest RLC unit
+func (a Amount) NRLC() *big.Int {
+	return a.Shift(RLCDecimals).BigInt()
+}
@@ -0,0 +1,191 @@
+package thegraph
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestTimestampUnmarshal(t *testing.T) {
+	tests := []struct {
+		input   string
+		unix    int64
+		zero    bool
+		wantErr bool
+	}{
+		{input: `"1735689600"`, unix: 1735689600},
+		{input: `1735689600`, unix: 1735689600},
+		{input: `null`, zero: true},
+		{input: `"tomorrow"`, wantErr: true},
+		{input: `"1.5"`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		var ts Timestamp
+
+		err := json.Unmarshal([]byte(tt.input), &ts)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("%s: expected an error", tt.input)
+			}
+
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("%s: expected no error, got %v", tt.input, err)
+			continue
+		}
+
+		if tt.zero != ts.IsZero() || (!tt.zero && ts.Unix() != tt.unix) {
+			t.Errorf("%s: unexpected timestamp %v", tt.input, ts.Time)
+		}
+	}
+}
+
+func TestTimestampRoundTrip(t *testing.T) {
+	var ts Timestamp
+	if err := json.Unmarshal([]byte(`"123456"`), &ts); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	data, err := json.Marshal(ts)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if string(data) != `"123456"` {
+		t.Errorf("expected \"123456\", got %s", data)
+	}
+}
+
+func TestSecondsUnmarshal(t *testing.T) {
+	var s Seconds
+	if err := json.Unmarshal([]byte(`"2592000"`), &s); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if s.Hours() != 720 {
+		t.Errorf("expected 720h, got %s", s.Duration)
+	}
+
+	if err := json.Unmarshal([]byte(`"a month"`), &s); err == nil {
+		t.Error("expected an error for a malformed duration")
+	}
+}
+
+func TestBytesText(t *testing.T) {
+	tests := []struct {
+		input Bytes
+		want  string
+	}{
+		{input: "0x697066733a2f2f516d", want: "ipfs://Qm"},
+		{input: "0x0001ff", want: "0x0001ff"},
+		{input: "0x", want: "0x"},
+		{input: "not hex", want: "not hex"},
+	}
+
+	for _, tt := range tests {
+		if tt.input.Text() != tt.want {
+			t.Errorf("%s: expected '%s', got '%s'", tt.input, tt.want, tt.input.Text())
+		}
+	}
+}
+
+func TestAmountUnmarshal(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    string
+		nrlc    string
+		wantErr bool
+	}{
+		{input: `"12.345678912"`, want: "12.345678912", nrlc: "12345678912"},
+		{input: `"100"`, want: "100", nrlc: "100000000000"},
+		{input: `0.1`, want: "0.1", nrlc: "100000000"},
+		{input: `null`, want: "0", nrlc: "0"},
+		{input: `"lots"`, wantErr: true},
+		{input: `{}`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		var amount Amount
+
+		err := json.Unmarshal([]byte(tt.input), &amount)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("%s: expected an error", tt.input)
+			}
+
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("%s: expected no error, got %v", tt.input, err)
+			continue
+		}
+
+		if amount.String() != tt.want {
+			t.Errorf("%s: expected %s, got %s", tt.input, tt.want, amount.String())
+		}
+
+		if amount.NRLC().String() != tt.nrlc {
+			t.Errorf("%s: expected %s nRLC, got %s", tt.input, tt.nrlc, amount.NRLC())
+		}
+	}
+}
+
+func TestAmountExactArithmetic(t *testing.T) {
+	var a, b Amount
+	_ = json.Unmarshal([]byte(`"0.1"`), &a)
+	_ = json.Unmarshal([]byte(`"0.2"`), &b)
+
+	if sum := a.Add(b.Decimal); sum.String() != "0.3" {
+		t.Errorf("expected 0.3, got %s", sum)
+	}
+}
+
+func TestBigIntUnmarshal(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    string
+		wantErr bool
+	}{
+		{input: `"115792089237316195423570985008687907853269984665640564039457584007913129639935"`,
+			want: "115792089237316195423570985008687907853269984665640564039457584007913129639935"},
+		{input: `42`, want: "42"},
+		{input: `null`, want: "0"},
+		{input: `"1.5"`, wantErr: true},
+		{input: `"many"`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		var i BigInt
+
+		err := json.Unmarshal([]byte(tt.input), &i)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("%s: expected an error", tt.input)
+			}
+
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("%s: expected no error, got %v", tt.input, err)
+			continue
+		}
+
+		data, err := json.Marshal(i)
+		if err != nil || string(data) != `"`+tt.want+`"` {
+			t.Errorf("%s: expected %q, got %s (%v)", tt.input, tt.want, data, err)
+		}
+	}
+}
+
+func TestVoucherMalformedPayload(t *testing.T) {
+	var voucher Voucher
+
+	err := json.Unmarshal([]byte(`{"id": "1", "expiration": "soon"}`), &voucher)
+	if err == nil {
+		t.Error("expected an error for a malformed expiration")
+	}
+}
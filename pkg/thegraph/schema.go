@@ -0,0 +1,284 @@
+package thegraph
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+const schemaCacheTTL = time.Hour
+
+var errEntityNotFound = errors.New("entity not found in subgraph schema")
+
+const introspectionQuery = `
+	query introspection {
+		__schema {
+			queryType {
+				name
+			}
+			types {
+				kind
+				name
+				description
+				fields {
+					name
+					description
+					args {
+						name
+						type {
+							...typeRef
+						}
+					}
+					type {
+						...typeRef
+					}
+				}
+				inputFields {
+					name
+					type {
+						...typeRef
+					}
+				}
+				enumValues {
+					name
+				}
+			}
+		}
+	}
+
+	fragment typeRef on __Type {
+		kind
+		name
+		ofType {
+			kind
+			name
+			ofType {
+				kind
+				name
+				ofType {
+					kind
+					name
+				}
+			}
+		}
+	}`
+
+// schemaCache keeps the schemas of the subgraphs by URL
+type schemaCache struct {
+	mu      sync.Mutex
+	entries map[string]schemaEntry
+}
+
+type schemaEntry struct {
+	schema    Schema
+	fetchedAt time.Time
+}
+
+func newSchemaCache() *schemaCache {
+	return &schemaCache{entries: make(map[string]schemaEntry)}
+}
+
+func (sc *schemaCache) get(key string) (Schema, bool) {
+	if sc == nil {
+		return Schema{}, false
+	}
+
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+
+	entry, found := sc.entries[key]
+	if !found || time.Since(entry.fetchedAt) > schemaCacheTTL {
+		return Schema{}, false
+	}
+
+	return entry.schema, true
+}
+
+func (sc *schemaCache) set(key string, schema Schema) {
+	if sc == nil {
+		return
+	}
+
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+
+	sc.entries[key] = schemaEntry{schema: schema, fetchedAt: time.Now()}
+}
+
+// GetSchema fetches the schema of a subgraph, given by name or by path, schemas are cached for an hour
+func (c *Client) GetSchema(ctx context.Context, endpoint string) (Schema, error) {
+	target, err := c.resolve(endpoint)
+	if err != nil {
+		return Schema{}, err
+	}
+
+	if schema, found := c.schemas.get(target.url); found {
+		return schema, nil
+	}
+
+	var response struct {
+		Data struct {
+			Schema Schema `json:"__schema"`
+		} `json:"data"`
+	}
+
+	if err := c.fetchGraphQLData(ctx, endpoint, introspectionQuery, nil, &response); err != nil {
+		return Schema{}, err
+	}
+
+	c.schemas.set(target.url, response.Data.Schema)
+
+	return response.Data.Schema, nil
+}
+
+// Type returns the type of the schema with this name
+func (s Schema) Type(name string) (SchemaType, bool) {
+	for _, t := range s.Types {
+		if t.Name == name {
+			return t, true
+		}
+	}
+
+	return SchemaType{}, false
+}
+
+// Entities lists the entities which can be listed from the root of the schema, in the schema order
+func (s Schema) Entities() []Entity {
+	queryType, found := s.Type(s.QueryType.Name)
+	if !found {
+		return nil
+	}
+
+	var entities []Entity
+
+	for _, field := range queryType.Fields {
+		if field.Type.unwrap().Kind != "LIST" || !slices.ContainsFunc(field.Args, func(arg InputValue) bool { return arg.Name == "where" }) {
+			continue
+		}
+
+		entityType, found := s.Type(field.Type.Named())
+		if !found || (entityType.Kind != "OBJECT" && entityType.Kind != "INTERFACE") {
+			continue
+		}
+
+		entity := Entity{
+			Name:        entityType.Name,
+			Description: entityType.Description,
+			Collection:  field.Name,
+			Filters:     make(map[string][]string),
+		}
+
+		for _, single := range queryType.Fields {
+			if single.Type.unwrap().Kind != "LIST" && single.Type.Named() == entity.Name {
+				entity.Single = single.Name
+				break
+			}
+		}
+
+		for _, f := range entityType.Fields {
+			entityField := EntityField{Name: f.Name, Type: f.Type.String()}
+			if related, found := s.Type(f.Type.Named()); found && (related.Kind == "OBJECT" || related.Kind == "INTERFACE") {
+				entityField.Relation = related.Name
+			}
+			entity.Fields = append(entity.Fields, entityField)
+		}
+
+		for _, arg := range field.Args {
+			switch arg.Name {
+			case "where":
+				if filterType, found := s.Type(arg.Type.Named()); found {
+					entity.filterType = filterType.Name
+					entity.Filters = filterOperators(entity.Fields, filterType.InputFields)
+				}
+			case "orderBy":
+				if orderType, found := s.Type(arg.Type.Named()); found {
+					for _, value := range orderType.EnumValues {
+						entity.OrderBy = append(entity.OrderBy, value.Name)
+					}
+				}
+			}
+		}
+
+		entities = append(entities, entity)
+	}
+
+	return entities
+}
+
+// Entity returns the entity of the schema with this name, matched case insensitively
+// on its type, collection or single field name
+func (s Schema) Entity(name string) (Entity, error) {
+	for _, entity := range s.Entities() {
+		if strings.EqualFold(entity.Name, name) || strings.EqualFold(entity.Collection, name) || strings.EqualFold(entity.Single, name) {
+			return entity, nil
+		}
+	}
+
+	return Entity{}, errEntityNotFound
+}
+
+// filterOperators groups the inputs of a where filter by entity field, e.g. balance_gt is the gt operator of balance
+func filterOperators(fields []EntityField, inputs []InputValue) map[string][]string {
+	filters := make(map[string][]string)
+
+	for _, input := range inputs {
+		// Longest field name first, a field name may contain an underscore
+		var field string
+		for _, f := range fields {
+			if (input.Name == f.Name || strings.HasPrefix(input.Name, f.Name+"_")) && len(f.Name) > len(field) {
+				field = f.Name
+			}
+		}
+
+		if field == "" {
+			continue
+		}
+
+		operator := strings.TrimPrefix(strings.TrimPrefix(input.Name, field), "_")
+		switch {
+		case input.Name == field:
+			operator = "eq"
+		case operator == "":
+			operator = "nested"
+		}
+
+		filters[field] = append(filters[field], operator)
+	}
+
+	return filters
+}
+
+// unwrap returns the type without its NON_NULL wrapper
+func (t TypeRef) unwrap() TypeRef {
+	if t.Kind == "NON_NULL" && t.OfType != nil {
+		return *t.OfType
+	}
+
+	return t
+}
+
+// Named returns the name of the type without its NON_NULL and LIST wrappers
+func (t TypeRef) Named() string {
+	if t.OfType != nil {
+		return t.OfType.Named()
+	}
+
+	return t.Name
+}
+
+// String formats the type in the GraphQL notation, e.g. [Deal!]!
+func (t TypeRef) String() string {
+	switch {
+	case t.OfType == nil:
+		return t.Name
+	case t.Kind == "NON_NULL":
+		return t.OfType.String() + "!"
+	case t.Kind == "LIST":
+		return "[" + t.OfType.String() + "]"
+	default:
+		return t.OfType.String()
+	}
+}
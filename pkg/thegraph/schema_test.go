@@ -0,0 +1,153 @@
+package thegraph
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"slices"
+	"testing"
+)
+
+const mockSchemaResponse = `{
+	"data": {
+		"__schema": {
+			"queryType": {"name": "Query"},
+			"types": [
+				{
+					"kind": "OBJECT",
+					"name": "Query",
+					"fields": [
+						{
+							"name": "voucher",
+							"args": [{"name": "id", "type": {"kind": "NON_NULL", "ofType": {"kind": "SCALAR", "name": "ID"}}}],
+							"type": {"kind": "OBJECT", "name": "Voucher"}
+						},
+						{
+							"name": "vouchers",
+							"args": [
+								{"name": "first", "type": {"kind": "SCALAR", "name": "Int"}},
+								{"name": "orderBy", "type": {"kind": "ENUM", "name": "Voucher_orderBy"}},
+								{"name": "where", "type": {"kind": "INPUT_OBJECT", "name": "Voucher_filter"}}
+							],
+							"type": {"kind": "NON_NULL", "ofType": {"kind": "LIST", "ofType": {"kind": "NON_NULL", "ofType": {"kind": "OBJECT", "name": "Voucher"}}}}
+						},
+						{
+							"name": "_meta",
+							"args": [],
+							"type": {"kind": "OBJECT", "name": "_Meta_"}
+						}
+					]
+				},
+				{
+					"kind": "OBJECT",
+					"name": "Voucher",
+					"description": "A voucher",
+					"fields": [
+						{"name": "id", "type": {"kind": "NON_NULL", "ofType": {"kind": "SCALAR", "name": "ID"}}},
+						{"name": "owner", "type": {"kind": "NON_NULL", "ofType": {"kind": "OBJECT", "name": "Account"}}},
+						{"name": "balance", "type": {"kind": "NON_NULL", "ofType": {"kind": "SCALAR", "name": "BigDecimal"}}},
+						{"name": "balance_history", "type": {"kind": "LIST", "ofType": {"kind": "SCALAR", "name": "BigDecimal"}}}
+					]
+				},
+				{
+					"kind": "INPUT_OBJECT",
+					"name": "Voucher_filter",
+					"inputFields": [
+						{"name": "id", "type": {"kind": "SCALAR", "name": "ID"}},
+						{"name": "id_gt", "type": {"kind": "SCALAR", "name": "ID"}},
+						{"name": "owner", "type": {"kind": "SCALAR", "name": "String"}},
+						{"name": "owner_", "type": {"kind": "INPUT_OBJECT", "name": "Account_filter"}},
+						{"name": "balance_gte", "type": {"kind": "SCALAR", "name": "BigDecimal"}},
+						{"name": "balance_history_contains", "type": {"kind": "LIST", "ofType": {"kind": "SCALAR", "name": "BigDecimal"}}},
+						{"name": "and", "type": {"kind": "LIST", "ofType": {"kind": "INPUT_OBJECT", "name": "Voucher_filter"}}}
+					]
+				},
+				{
+					"kind": "ENUM",
+					"name": "Voucher_orderBy",
+					"enumValues": [{"name": "id"}, {"name": "balance"}]
+				},
+				{
+					"kind": "OBJECT",
+					"name": "Account",
+					"fields": [{"name": "id", "type": {"kind": "NON_NULL", "ofType": {"kind": "SCALAR", "name": "ID"}}}]
+				}
+			]
+		}
+	}
+}`
+
+func TestGetSchema(t *testing.T) {
+	calls := 0
+
+	transport := &MockRoundTripper{RoundTripFunc: func(req *http.Request) (*http.Response, error) {
+		calls++
+		return newStatusResponse(200, mockSchemaResponse), nil
+	}}
+
+	client := NewClient("http://mocked", WithTransport(transport))
+
+	schema, err := client.GetSchema(context.Background(), VoucherSubgraph)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if _, err := client.AtBlock(BlockNumber(42)).GetSchema(context.Background(), VoucherEndpoint); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if calls != 1 {
+		t.Errorf("expected the schema to be cached, got %d requests", calls)
+	}
+
+	entities := schema.Entities()
+	if len(entities) != 1 {
+		t.Fatalf("expected a single entity, got %+v", entities)
+	}
+
+	voucher := entities[0]
+	if voucher.Name != "Voucher" || voucher.Collection != "vouchers" || voucher.Single != "voucher" {
+		t.Errorf("unexpected entity %+v", voucher)
+	}
+
+	if len(voucher.Fields) != 4 || voucher.Fields[1].Relation != "Account" || voucher.Fields[1].Type != "Account!" || voucher.Fields[3].Type != "[BigDecimal]" {
+		t.Errorf("unexpected fields %+v", voucher.Fields)
+	}
+
+	expected := map[string][]string{
+		"id":              {"eq", "gt"},
+		"owner":           {"eq", "nested"},
+		"balance":         {"gte"},
+		"balance_history": {"contains"},
+	}
+	for field, operators := range expected {
+		if !slices.Equal(voucher.Filters[field], operators) {
+			t.Errorf("expected %s operators %v, got %v", field, operators, voucher.Filters[field])
+		}
+	}
+
+	if !slices.Equal(voucher.OrderBy, []string{"id", "balance"}) {
+		t.Errorf("unexpected orderBy %v", voucher.OrderBy)
+	}
+}
+
+func TestSchemaEntity(t *testing.T) {
+	client := newMockedClient(func(req *http.Request) (*http.Response, error) {
+		return newStatusResponse(200, mockSchemaResponse), nil
+	})
+
+	schema, err := client.GetSchema(context.Background(), VoucherSubgraph)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	for _, name := range []string{"Voucher", "vouchers", "VOUCHER"} {
+		if entity, err := schema.Entity(name); err != nil || entity.Name != "Voucher" {
+			t.Errorf("%s: expected the Voucher entity, got %+v, %v", name, entity, err)
+		}
+	}
+
+	if _, err := schema.Entity("Deal"); !errors.Is(err, errEntityNotFound) {
+		t.Errorf("expected errEntityNotFound, got %v", err)
+	}
+}
@@ -0,0 +1,178 @@
+package thegraph
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"net/http"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Names of the iExec subgraphs known to the client
+const (
+	VoucherSubgraph = "voucher"
+	PocoSubgraph    = "poco"
+)
+
+const subgraph_name_regex = `^[A-Za-z0-9_\-]+$`
+
+var subgraphNameRegexp = regexp.MustCompile(subgraph_name_regex)
+
+var (
+	errInvalidSubgraph = errors.New("invalid subgraph")
+	errInvalidConfig   = errors.New("invalid TheGraph config")
+)
+
+// defaultSubgraphs are the paths of the iExec subgraphs under the TheGraph URL
+var defaultSubgraphs = map[string]string{
+	VoucherSubgraph: VoucherEndpoint,
+	PocoSubgraph:    PocoEndpoint,
+}
+
+// Subgraph is a named subgraph served by its own URL, it overrides the default subgraph of the same name.
+// The client headers are only sent when URL is under the client URL, Headers are sent on top of them.
+// A zero Timeout keeps the client timeout
+type Subgraph struct {
+	Name    string
+	URL     string
+	Headers http.Header
+	Timeout time.Duration
+}
+
+// subgraphConfig is a subgraph as written in a config file
+type subgraphConfig struct {
+	URL     string            `json:"url"`
+	APIKey  string            `json:"apiKey,omitempty"`
+	Headers map[string]string `json:"headers,omitempty"`
+	Timeout string            `json:"timeout,omitempty"`
+}
+
+type config struct {
+	Subgraphs map[string]subgraphConfig `json:"subgraphs"`
+}
+
+// target is where a GraphQL request is sent, base tells whether url is under the client URL
+type target struct {
+	url     string
+	base    bool
+	headers http.Header
+	timeout time.Duration
+}
+
+// WithSubgraph registers a named subgraph
+func WithSubgraph(subgraph Subgraph) Option {
+	return func(c *Client) {
+		if c.subgraphs == nil {
+			c.subgraphs = make(map[string]Subgraph)
+		}
+		c.subgraphs[subgraph.Name] = subgraph
+	}
+}
+
+// ParseSubgraph parses a subgraph written as name=url
+func ParseSubgraph(value string) (Subgraph, error) {
+	name, url, found := strings.Cut(strings.TrimSpace(value), "=")
+	if !found {
+		return Subgraph{}, fmt.Errorf("%w %q, expected name=url", errInvalidSubgraph, value)
+	}
+
+	subgraph := Subgraph{Name: strings.TrimSpace(name), URL: strings.TrimSpace(url)}
+
+	return subgraph, subgraph.validate()
+}
+
+// LoadSubgraphs reads the subgraphs of a JSON config file such as
+//
+//	{"subgraphs": {"poco": {"url": "http://localhost:8000/subgraphs/name/poco", "apiKey": "", "headers": {}, "timeout": "5s"}}}
+func LoadSubgraphs(path string) ([]Subgraph, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", errInvalidConfig, err)
+	}
+
+	var cfg config
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("%w %s: %v", errInvalidConfig, path, err)
+	}
+
+	subgraphs := make([]Subgraph, 0, len(cfg.Subgraphs))
+
+	for _, name := range slices.Sorted(maps.Keys(cfg.Subgraphs)) {
+		entry := cfg.Subgraphs[name]
+		subgraph := Subgraph{Name: name, URL: entry.URL, Headers: make(http.Header)}
+
+		for key, value := range entry.Headers {
+			subgraph.Headers.Set(key, value)
+		}
+
+		if entry.APIKey != "" {
+			subgraph.Headers.Set("Authorization", "Bearer "+entry.APIKey)
+		}
+
+		if entry.Timeout != "" {
+			subgraph.Timeout, err = time.ParseDuration(entry.Timeout)
+			if err != nil {
+				return nil, fmt.Errorf("%w %s: subgraph %s: %v", errInvalidConfig, path, name, err)
+			}
+		}
+
+		if err := subgraph.validate(); err != nil {
+			return nil, fmt.Errorf("%w %s: %v", errInvalidConfig, path, err)
+		}
+
+		subgraphs = append(subgraphs, subgraph)
+	}
+
+	return subgraphs, nil
+}
+
+func (s Subgraph) validate() error {
+	if !subgraphNameRegexp.MatchString(s.Name) {
+		return fmt.Errorf("%w: invalid name %q", errInvalidSubgraph, s.Name)
+	}
+
+	if !strings.HasPrefix(s.URL, "http://") && !strings.HasPrefix(s.URL, "https://") {
+		return fmt.Errorf("%w %s: invalid URL %q", errInvalidSubgraph, s.Name, s.URL)
+	}
+
+	return nil
+}
+
+// Subgraphs lists the names of the subgraphs known to the client
+func (c *Client) Subgraphs() []string {
+	names := slices.Collect(maps.Keys(defaultSubgraphs))
+	for name := range c.subgraphs {
+		if !slices.Contains(names, name) {
+			names = append(names, name)
+		}
+	}
+	slices.Sort(names)
+
+	return names
+}
+
+// resolve finds where to send a request for a subgraph name or a path under baseURL
+func (c *Client) resolve(endpoint string) (target, error) {
+	endpoint = strings.TrimSpace(endpoint)
+
+	if subgraph, found := c.subgraphs[endpoint]; found {
+		base := subgraph.URL == c.baseURL || strings.HasPrefix(subgraph.URL, strings.TrimSuffix(c.baseURL, "/")+"/")
+		return target{url: subgraph.URL, base: base, headers: subgraph.Headers, timeout: subgraph.Timeout}, nil
+	}
+
+	if path, found := defaultSubgraphs[endpoint]; found {
+		return target{url: c.baseURL + path, base: true}, nil
+	}
+
+	path, err := normalizeEndpoint(endpoint)
+	if err != nil {
+		return target{}, err
+	}
+
+	return target{url: c.baseURL + path, base: true}, nil
+}
@@ -0,0 +1,143 @@
+package thegraph
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestParseSubgraph(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    Subgraph
+		wantErr bool
+	}{
+		{value: "poco=http://localhost:8000/subgraphs/name/poco", want: Subgraph{Name: "poco", URL: "http://localhost:8000/subgraphs/name/poco"}},
+		{value: " my-subgraph = https://example.com/sub ", want: Subgraph{Name: "my-subgraph", URL: "https://example.com/sub"}},
+		{value: "poco", wantErr: true},
+		{value: "=http://localhost", wantErr: true},
+		{value: "po/co=http://localhost", wantErr: true},
+		{value: "poco=localhost:8000", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		subgraph, err := ParseSubgraph(tt.value)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseSubgraph(%q): unexpected error %v", tt.value, err)
+			continue
+		}
+
+		if !tt.wantErr && (subgraph.Name != tt.want.Name || subgraph.URL != tt.want.URL) {
+			t.Errorf("ParseSubgraph(%q): expected %+v, got %+v", tt.value, tt.want, subgraph)
+		}
+	}
+}
+
+func TestLoadSubgraphs(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "thegraph.json")
+	config := `{
+		"subgraphs": {
+			"poco": {"url": "http://localhost:8000/subgraphs/name/poco", "timeout": "5s"},
+			"custom": {"url": "https://gateway.example.com/sub", "apiKey": "secret", "headers": {"X-Team": "support"}}
+		}
+	}`
+
+	if err := os.WriteFile(path, []byte(config), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	subgraphs, err := LoadSubgraphs(path)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if len(subgraphs) != 2 || subgraphs[0].Name != "custom" || subgraphs[1].Name != "poco" {
+		t.Fatalf("unexpected subgraphs %+v", subgraphs)
+	}
+
+	if subgraphs[0].Headers.Get("Authorization") != "Bearer secret" || subgraphs[0].Headers.Get("X-Team") != "support" {
+		t.Errorf("unexpected headers %v", subgraphs[0].Headers)
+	}
+
+	if subgraphs[1].Timeout != 5*time.Second {
+		t.Errorf("expected timeout 5s, got %s", subgraphs[1].Timeout)
+	}
+}
+
+func TestLoadSubgraphsInvalid(t *testing.T) {
+	dir := t.TempDir()
+
+	for name, config := range map[string]string{
+		"json":    `{"subgraphs": `,
+		"url":     `{"subgraphs": {"poco": {"url": ""}}}`,
+		"timeout": `{"subgraphs": {"poco": {"url": "http://localhost", "timeout": "soon"}}}`,
+	} {
+		path := filepath.Join(dir, name+".json")
+		if err := os.WriteFile(path, []byte(config), 0o600); err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := LoadSubgraphs(path); !errors.Is(err, errInvalidConfig) {
+			t.Errorf("%s: expected errInvalidConfig, got %v", name, err)
+		}
+	}
+
+	if _, err := LoadSubgraphs(filepath.Join(dir, "missing.json")); !errors.Is(err, errInvalidConfig) {
+		t.Errorf("expected errInvalidConfig for a missing file, got %v", err)
+	}
+}
+
+func TestClientRoutesNamedSubgraphs(t *testing.T) {
+	requests := make(map[string]http.Header)
+
+	transport := &MockRoundTripper{RoundTripFunc: func(req *http.Request) (*http.Response, error) {
+		requests[req.URL.String()] = req.Header
+		return newStatusResponse(200, `{"data": {}}`), nil
+	}}
+
+	headers := make(http.Header)
+	headers.Set("Authorization", "Bearer self-hosted")
+
+	client := NewClient("http://public",
+		WithTransport(transport),
+		WithAPIKey("public"),
+		WithSubgraph(Subgraph{Name: PocoSubgraph, URL: "http://self-hosted/poco", Headers: headers, Timeout: time.Second}),
+		WithSubgraph(Subgraph{Name: "custom", URL: "http://custom/sub"}),
+		WithSubgraph(Subgraph{Name: "mirror", URL: "http://public/mirror"}),
+	)
+
+	for _, endpoint := range []string{VoucherSubgraph, PocoSubgraph, "custom", "mirror", "/other"} {
+		if _, err := client.Query(context.Background(), endpoint, "{ a }", nil); err != nil {
+			t.Fatalf("%s: expected no error, got %v", endpoint, err)
+		}
+	}
+
+	expected := map[string]string{
+		"http://public" + VoucherEndpoint: "Bearer public",
+		"http://self-hosted/poco":         "Bearer self-hosted",
+		"http://custom/sub":               "",
+		"http://public/mirror":            "Bearer public",
+		"http://public/other":             "Bearer public",
+	}
+
+	for url, authorization := range expected {
+		header, found := requests[url]
+		if !found {
+			t.Errorf("expected a request to %s, got %v", url, requests)
+			continue
+		}
+
+		if header.Get("Authorization") != authorization {
+			t.Errorf("%s: expected Authorization %q, got %q", url, authorization, header.Get("Authorization"))
+		}
+	}
+
+	if names := client.Subgraphs(); !slices.Equal(names, []string{"custom", "mirror", PocoSubgraph, VoucherSubgraph}) {
+		t.Errorf("unexpected subgraphs %v", names)
+	}
+}
@@ -0,0 +1,130 @@
+package thegraph
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"iter"
+	"strings"
+)
+
+// Task statuses as indexed by the PoCo subgraph
+const (
+	TaskStatusUnset     = "UNSET"
+	TaskStatusActive    = "ACTIVE"
+	TaskStatusRevealing = "REVEALING"
+	TaskStatusCompleted = "COMPLETED"
+	TaskStatusFailed    = "FAILED"
+)
+
+var errTaskNotFound = errors.New("task not found")
+
+const taskFields = `
+			id
+			index
+			status
+			deal {
+				id
+				botSize
+				requester {
+					id
+				}
+				app {
+					id
+				}
+				workerpool {
+					id
+				}
+			}
+			timestamp
+			contributionDeadline
+			revealDeadline
+			finalDeadline
+			consensus
+			resultDigest
+			results
+			contributions(orderBy: timestamp, orderDirection: asc) {
+				id
+				status
+				worker {
+					id
+				}
+				timestamp
+			}
+		`
+
+var taskCollection = collection{
+	endpoint:   PocoSubgraph,
+	name:       "tasks",
+	filterType: "Task_filter",
+	fields:     taskFields,
+}
+
+// GetTask fetches a single task with its contributions
+func (c *Client) GetTask(ctx context.Context, id string) (Task, error) {
+	task, found, err := fetchByID[Task](ctx, c, PocoSubgraph, "task", taskFields, id)
+	if err == nil && !found {
+		err = errTaskNotFound
+	}
+
+	return task, err
+}
+
+// GetTasksPage fetches at most limit tasks matching filter with an ID greater than cursor
+func (c *Client) GetTasksPage(ctx context.Context, filter TaskFilter, cursor string, limit int) (Page[Task], error) {
+	return fetchPage[Task](ctx, c, taskCollection, filter.where(), cursor, limit)
+}
+
+// Tasks streams all tasks matching filter, walking the pages by ID
+func (c *Client) Tasks(ctx context.Context, filter TaskFilter) iter.Seq2[Task, error] {
+	return walk[Task](ctx, c, taskCollection, filter.where())
+}
+
+// where translates the filter into a GraphQL Task_filter input
+func (f TaskFilter) where() map[string]interface{} {
+	where := map[string]interface{}{}
+
+	if f.Deal != "" {
+		where["deal"] = strings.ToLower(f.Deal)
+	}
+
+	if f.Status != "" {
+		where["status"] = strings.ToUpper(f.Status)
+	}
+
+	return where
+}
+
+// ResultLocation returns where the task result was pushed, the results are
+// stored on chain as hex encoded JSON such as {"storage":"ipfs","location":"/ipfs/..."}
+func (t Task) ResultLocation() string {
+	raw := strings.TrimPrefix(t.Results, "0x")
+	if raw == "" {
+		return ""
+	}
+
+	data, err := hex.DecodeString(raw)
+	if err != nil {
+		return t.Results
+	}
+
+	var result struct {
+		Storage  string `json:"storage"`
+		Location string `json:"location"`
+	}
+
+	if err := json.Unmarshal(data, &result); err != nil || result.Location == "" {
+		return string(data)
+	}
+
+	if result.Storage == "" {
+		return result.Location
+	}
+
+	return result.Storage + ":" + result.Location
+}
+
+func (t Task) entityID() string {
+	return t.ID
+}
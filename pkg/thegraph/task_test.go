@@ -0,0 +1,107 @@
+package thegraph
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+)
+
+func TestGetTask(t *testing.T) {
+	results := "0x" + hex.EncodeToString([]byte(`{"storage":"ipfs","location":"/ipfs/QmResult"}`))
+	mockResponse := `{
+		"data": {
+			"task": {
+				"id": "0xtask",
+				"index": "0",
+				"status": "REVEALING",
+				"deal": {"id": "0xdeal", "requester": {"id": "0xrequester"}},
+				"finalDeadline": "1733003000",
+				"results": "` + results + `",
+				"contributions": [
+					{"id": "0xc1", "status": "CONTRIBUTED", "worker": {"id": "0xworker"}, "timestamp": "1733001000"}
+				]
+			}
+		}
+	}`
+
+	client := newMockedClient(func(req *http.Request) (*http.Response, error) {
+		return newStatusResponse(200, mockResponse), nil
+	})
+
+	task, err := client.GetTask(context.Background(), "0xTASK")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if task.Status != TaskStatusRevealing || task.Deal == nil || task.Deal.ID != "0xdeal" {
+		t.Errorf("unexpected task %+v", task)
+	}
+
+	if len(task.Contributions) != 1 || task.Contributions[0].Worker.ID != "0xworker" {
+		t.Errorf("unexpected contributions %+v", task.Contributions)
+	}
+
+	if task.ResultLocation() != "ipfs:/ipfs/QmResult" {
+		t.Errorf("expected result location 'ipfs:/ipfs/QmResult', got '%s'", task.ResultLocation())
+	}
+}
+
+func TestGetTaskNotFound(t *testing.T) {
+	client := newMockedClient(func(req *http.Request) (*http.Response, error) {
+		return newStatusResponse(200, `{"data": {"task": null}}`), nil
+	})
+
+	_, err := client.GetTask(context.Background(), "0xunknown")
+	if !errors.Is(err, errTaskNotFound) {
+		t.Errorf("expected errTaskNotFound, got %v", err)
+	}
+}
+
+func TestGetTasksPageByDeal(t *testing.T) {
+	var payload graphQLRequest
+
+	client := newMockedClient(func(req *http.Request) (*http.Response, error) {
+		if err := json.NewDecoder(req.Body).Decode(&payload); err != nil {
+			t.Fatalf("failed to decode request body: %v", err)
+		}
+
+		return newStatusResponse(200, `{"data": {"tasks": [{"id": "0xtask", "status": "ACTIVE"}]}}`), nil
+	})
+
+	page, err := client.GetTasksPage(context.Background(), TaskFilter{Deal: "0xDEAL", Status: "active"}, "", 0)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if len(page.Items) != 1 {
+		t.Fatalf("expected 1 task, got %d", len(page.Items))
+	}
+
+	where, _ := payload.Variables["where"].(map[string]interface{})
+	if where["deal"] != "0xdeal" || where["status"] != TaskStatusActive {
+		t.Errorf("unexpected where %v", where)
+	}
+}
+
+func TestTaskResultLocation(t *testing.T) {
+	tests := []struct {
+		results string
+		want    string
+	}{
+		{results: "", want: ""},
+		{results: "0x", want: ""},
+		{results: "0x" + hex.EncodeToString([]byte(`{"location":"https://result"}`)), want: "https://result"},
+		{results: "0x" + hex.EncodeToString([]byte("raw result")), want: "raw result"},
+		{results: "not hex", want: "not hex"},
+	}
+
+	for _, tt := range tests {
+		task := Task{Results: tt.results}
+		if task.ResultLocation() != tt.want {
+			t.Errorf("%q: expected '%s', got '%s'", tt.results, tt.want, task.ResultLocation())
+		}
+	}
+}
@@ -0,0 +1,224 @@
+package thegraph
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+const (
+	templateExtension   = ".graphql"
+	frontMatterMarker   = "---"
+	template_name_regex = `^[A-Za-z][A-Za-z0-9_\-]*$`
+)
+
+var (
+	errInvalidTemplate = errors.New("invalid query template")
+	errMissingVariable = errors.New("missing required variable")
+)
+
+var templateNameRegexp = regexp.MustCompile(template_name_regex)
+
+// Template is a persisted GraphQL query read from a .graphql file starting with a front-matter
+// written in comments, variable types come from the query and the front-matter describes them:
+//
+//	# ---
+//	# name: voucherOwners
+//	# description: Owners of the vouchers of a type
+//	# endpoint: voucher
+//	# variables:
+//	#   type: The voucher type ID
+//	#   first: Maximum number of vouchers
+//	# ---
+//	query voucherOwners($type: String!, $first: Int = 100) { ... }
+type Template struct {
+	Name        string
+	Description string
+	Endpoint    string
+	Query       string
+	Variables   []TemplateVariable
+}
+
+// TemplateVariable is a variable of a template, Required is true for a non null variable without default value
+type TemplateVariable struct {
+	Name        string
+	Type        string
+	Required    bool
+	Description string
+}
+
+// LoadTemplates reads the .graphql templates of a directory, sorted by file name. A template named
+// like a reserved name, e.g. an already registered tool, is rejected
+func LoadTemplates(dir string, reserved []string) ([]Template, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*"+templateExtension))
+	if err != nil {
+		return nil, err
+	}
+
+	templates := make([]Template, 0, len(paths))
+	names := make(map[string]string)
+
+	for _, path := range paths {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+
+		template, err := ParseTemplate(strings.TrimSuffix(filepath.Base(path), templateExtension), string(content))
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+
+		if slices.Contains(reserved, template.Name) {
+			return nil, fmt.Errorf("%w: %s is named %s which is already taken", errInvalidTemplate, path, template.Name)
+		}
+
+		if other, found := names[template.Name]; found {
+			return nil, fmt.Errorf("%w: %s and %s are both named %s", errInvalidTemplate, other, path, template.Name)
+		}
+		names[template.Name] = path
+
+		templates = append(templates, template)
+	}
+
+	return templates, nil
+}
+
+// ParseTemplate parses a template, the name defaults to defaultName when the front-matter has none
+func ParseTemplate(defaultName, content string) (Template, error) {
+	template := Template{Name: defaultName}
+
+	descriptions, query, err := template.parseFrontMatter(content)
+	if err != nil {
+		return template, err
+	}
+	template.Query = strings.TrimSpace(query)
+
+	if !templateNameRegexp.MatchString(template.Name) {
+		return template, fmt.Errorf("%w: invalid name %q", errInvalidTemplate, template.Name)
+	}
+
+	// Templates are trusted, only the shape of the document is checked
+	if err := ValidateQuery(template.Query, nil, QueryLimits{}, nil); err != nil {
+		return template, fmt.Errorf("%w: %v", errInvalidTemplate, err)
+	}
+
+	doc, _ := parseDocument(template.Query)
+	for _, variable := range doc.operations[0].variables {
+		template.Variables = append(template.Variables, TemplateVariable{
+			Name:        variable.name,
+			Type:        variable.typ,
+			Required:    strings.HasSuffix(variable.typ, "!") && !variable.hasDefault,
+			Description: descriptions[variable.name],
+		})
+		delete(descriptions, variable.name)
+	}
+
+	if len(descriptions) > 0 {
+		name := slices.Sorted(maps.Keys(descriptions))[0]
+		return template, fmt.Errorf("%w: variable %s is described but not declared by the query", errInvalidTemplate, name)
+	}
+
+	return template, nil
+}
+
+// parseFrontMatter reads the front-matter fields into the template and returns the variable descriptions
+// and the query following the front-matter
+func (t *Template) parseFrontMatter(content string) (map[string]string, string, error) {
+	descriptions := make(map[string]string)
+
+	offset := 0
+	started, inVariables := false, false
+
+	for _, raw := range strings.SplitAfter(content, "\n") {
+		offset += len(raw)
+		line := strings.TrimSpace(raw)
+
+		if !started {
+			if line == "" {
+				continue
+			}
+
+			if line != "# "+frontMatterMarker && line != "#"+frontMatterMarker {
+				// No front-matter, the whole file is the query
+				return descriptions, content, nil
+			}
+			started = true
+
+			continue
+		}
+
+		if !strings.HasPrefix(line, "#") {
+			return nil, "", fmt.Errorf("%w: unterminated front-matter", errInvalidTemplate)
+		}
+
+		body := strings.TrimPrefix(strings.TrimRight(raw[strings.Index(raw, "#")+1:], "\r\n"), " ")
+		if strings.TrimSpace(body) == frontMatterMarker {
+			return descriptions, content[offset:], nil
+		}
+
+		if strings.TrimSpace(body) == "" {
+			continue
+		}
+
+		key, value, found := strings.Cut(body, ":")
+		if !found {
+			return nil, "", fmt.Errorf("%w: invalid front-matter line %q", errInvalidTemplate, line)
+		}
+		value = strings.TrimSpace(value)
+
+		// Variables are indented under the variables key
+		if inVariables && key != strings.TrimLeft(key, " \t") {
+			descriptions[strings.TrimSpace(key)] = value
+			continue
+		}
+		inVariables = false
+
+		switch strings.TrimSpace(key) {
+		case "name":
+			t.Name = value
+		case "description":
+			t.Description = value
+		case "endpoint":
+			t.Endpoint = value
+		case "variables":
+			inVariables = true
+		default:
+			return nil, "", fmt.Errorf("%w: unknown front-matter field %q", errInvalidTemplate, strings.TrimSpace(key))
+		}
+	}
+
+	if started {
+		return nil, "", fmt.Errorf("%w: unterminated front-matter", errInvalidTemplate)
+	}
+
+	return descriptions, content, nil
+}
+
+// Run executes the template with the arguments matching its variables, other arguments are ignored
+func (t Template) Run(ctx context.Context, c *Client, arguments map[string]interface{}) (json.RawMessage, error) {
+	variables := make(map[string]interface{}, len(t.Variables))
+
+	for _, variable := range t.Variables {
+		value, found := arguments[variable.Name]
+		if !found || value == nil {
+			if variable.Required {
+				return nil, fmt.Errorf("%w %s", errMissingVariable, variable.Name)
+			}
+
+			continue
+		}
+
+		variables[variable.Name] = value
+	}
+
+	// The variables come from the caller, the client QueryLimits apply as to any other query
+	return c.SafeQuery(ctx, t.Endpoint, t.Query, variables)
+}
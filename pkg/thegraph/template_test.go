@@ -0,0 +1,165 @@
+package thegraph
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const voucherOwnersTemplate = `# ---
+# name: voucherOwners
+# description: Owners of the vouchers of a type
+# endpoint: voucher
+# variables:
+#   type: The voucher type ID
+#   first: Maximum number of vouchers
+# ---
+query voucherOwners($type: String!, $first: Int = 100, $owners: [String!]) {
+	vouchers(first: $first, where: {voucherType: $type, owner_in: $owners}) {
+		owner {
+			id
+		}
+	}
+}
+`
+
+func TestParseTemplate(t *testing.T) {
+	template, err := ParseTemplate("file", voucherOwnersTemplate)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if template.Name != "voucherOwners" || template.Description != "Owners of the vouchers of a type" || template.Endpoint != VoucherSubgraph {
+		t.Errorf("unexpected template %+v", template)
+	}
+
+	if template.Query[:len("query voucherOwners")] != "query voucherOwners" {
+		t.Errorf("expected the query without its front-matter, got %s", template.Query)
+	}
+
+	expected := []TemplateVariable{
+		{Name: "type", Type: "String!", Required: true, Description: "The voucher type ID"},
+		{Name: "first", Type: "Int", Description: "Maximum number of vouchers"},
+		{Name: "owners", Type: "[String!]"},
+	}
+
+	if len(template.Variables) != len(expected) {
+		t.Fatalf("expected %d variables, got %+v", len(expected), template.Variables)
+	}
+
+	for i, variable := range template.Variables {
+		if variable != expected[i] {
+			t.Errorf("expected variable %+v, got %+v", expected[i], variable)
+		}
+	}
+}
+
+func TestParseTemplateWithoutFrontMatter(t *testing.T) {
+	template, err := ParseTemplate("allVouchers", "{ vouchers { id } }")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if template.Name != "allVouchers" || template.Endpoint != "" || len(template.Variables) != 0 {
+		t.Errorf("unexpected template %+v", template)
+	}
+}
+
+func TestParseTemplateInvalid(t *testing.T) {
+	tests := map[string]string{
+		"unterminated front-matter": "# ---\n# name: a\n{ vouchers { id } }",
+		"unknown field":             "# ---\n# author: me\n# ---\n{ vouchers { id } }",
+		"undeclared variable":       "# ---\n# variables:\n#   owner: The owner\n# ---\n{ vouchers { id } }",
+		"invalid name":              "# ---\n# name: my query\n# ---\n{ vouchers { id } }",
+		"mutation":                  "mutation { vouchers { id } }",
+		"syntax error":              "{ vouchers { id }",
+	}
+
+	for name, content := range tests {
+		if _, err := ParseTemplate("template", content); !errors.Is(err, errInvalidTemplate) {
+			t.Errorf("%s: expected errInvalidTemplate, got %v", name, err)
+		}
+	}
+}
+
+func TestLoadTemplates(t *testing.T) {
+	dir := t.TempDir()
+
+	files := map[string]string{
+		"owners.graphql": voucherOwnersTemplate,
+		"all.graphql":    "{ vouchers { id } }",
+		"types.graphql":  "# ---\n# endpoint: voucher\n# ---\n{ voucherTypes { id } }",
+		"notes.txt":      "not a template",
+	}
+
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	templates, err := LoadTemplates(dir, []string{"getVouchers"})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if len(templates) != 3 || templates[0].Name != "all" || templates[1].Name != "voucherOwners" || templates[2].Name != "types" {
+		t.Errorf("unexpected templates %+v", templates)
+	}
+
+	if _, err := LoadTemplates(dir, []string{"types"}); !errors.Is(err, errInvalidTemplate) {
+		t.Errorf("expected reserved names to be rejected, got %v", err)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "voucherOwners.graphql"), []byte("{ vouchers { id } }"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := LoadTemplates(dir, nil); !errors.Is(err, errInvalidTemplate) {
+		t.Errorf("expected duplicated names to be rejected, got %v", err)
+	}
+}
+
+func TestTemplateRun(t *testing.T) {
+	var payload graphQLRequest
+	var path string
+
+	client := newMockedClient(func(req *http.Request) (*http.Response, error) {
+		path = req.URL.Path
+		if err := json.NewDecoder(req.Body).Decode(&payload); err != nil {
+			t.Fatalf("failed to decode request body: %v", err)
+		}
+
+		return newStatusResponse(200, `{"data": {"vouchers": []}}`), nil
+	}, WithQueryLimits(DefaultQueryLimits))
+
+	template, err := ParseTemplate("file", voucherOwnersTemplate)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := template.Run(context.Background(), client, map[string]interface{}{"type": "1", "first": float64(5), "other": "ignored"})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if string(data) != `{"vouchers": []}` || path != VoucherEndpoint {
+		t.Errorf("unexpected data %s from %s", data, path)
+	}
+
+	if len(payload.Variables) != 2 || payload.Variables["type"] != "1" || payload.Variables["first"] != float64(5) {
+		t.Errorf("unexpected variables %v", payload.Variables)
+	}
+
+	if _, err := template.Run(context.Background(), client, nil); !errors.Is(err, errMissingVariable) {
+		t.Errorf("expected errMissingVariable, got %v", err)
+	}
+
+	if _, err := template.Run(context.Background(), client, map[string]interface{}{"type": "1", "first": float64(5000)}); !errors.Is(err, errRejectedQuery) {
+		t.Errorf("expected the query limits to apply, got %v", err)
+	}
+}
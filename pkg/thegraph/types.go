@@ -1,10 +1,62 @@
 package thegraph
 
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
 // #region Common struct
 type Owner struct {
 	ID string `json:"id,omitempty"`
 }
 
+// Page is a page of entities, NextCursor is empty when no more entities remain
+type Page[T any] struct {
+	Items      []T    `json:"items,omitempty"`
+	NextCursor string `json:"nextCursor,omitempty"`
+}
+
+type graphQLRequest struct {
+	Query     string                 `json:"query"`
+	Variables map[string]interface{} `json:"variables,omitempty"`
+}
+
+type QueryResponse struct {
+	Data json.RawMessage `json:"data,omitempty"`
+}
+
+type graphQLResponse struct {
+	Data   json.RawMessage `json:"data,omitempty"`
+	Errors []GraphQLError  `json:"errors,omitempty"`
+}
+
+type GraphQLError struct {
+	Message   string                 `json:"message"`
+	Locations []GraphQLErrorLocation `json:"locations,omitempty"`
+	Path      []interface{}          `json:"path,omitempty"`
+}
+
+type GraphQLErrorLocation struct {
+	Line   int `json:"line"`
+	Column int `json:"column"`
+}
+
+// Meta is the indexing status of a subgraph
+type Meta struct {
+	Deployment        string    `json:"deployment,omitempty"`
+	HasIndexingErrors bool      `json:"hasIndexingErrors"`
+	Block             MetaBlock `json:"block"`
+}
+
+// MetaBlock is the latest block indexed by a subgraph
+type MetaBlock struct {
+	Number    BigInt    `json:"number"`
+	Hash      Bytes     `json:"hash,omitempty"`
+	Timestamp Timestamp `json:"timestamp,omitempty"`
+}
+
 // #endregion
 
 // #region Voucher struct
@@ -12,20 +64,354 @@ type VoucherResponse struct {
 	Data VoucherData `json:"data,omitempty"`
 }
 type VoucherType struct {
-	ID   string `json:"id,omitempty"`
-	Desc string `json:"description,omitempty"`
+	ID             string  `json:"id,omitempty"`
+	Desc           string  `json:"description,omitempty"`
+	Duration       Seconds `json:"duration,omitempty"`
+	EligibleAssets []Asset `json:"eligibleAssets,omitempty"`
+}
+
+type VoucherTypeResponse struct {
+	Data VoucherTypeData `json:"data,omitempty"`
+}
+
+type VoucherTypeData struct {
+	VoucherTypes []VoucherType `json:"voucherTypes,omitempty"`
+}
+
+// VoucherTypeSummary is a voucher type with the number of its active vouchers, nil when not counted
+type VoucherTypeSummary struct {
+	VoucherType
+	ActiveVouchers *int `json:"activeVouchers,omitempty"`
+}
+
+type Asset struct {
+	ID        string `json:"id,omitempty"`
+	AssetType string `json:"assetType,omitempty"`
 }
 
 type Voucher struct {
 	VoucherType VoucherType `json:"voucherType,omitempty"`
 	ID          string      `json:"id,omitempty"`
 	Owner       Owner       `json:"owner,omitempty"`
-	Expiration  string      `json:"expiration,omitempty"`
-	Value       string      `json:"value,omitempty"`
-	Balance     string      `json:"balance,omitempty"`
+	Expiration  Timestamp   `json:"expiration,omitempty"`
+	Value       Amount      `json:"value,omitempty"`
+	Balance     Amount      `json:"balance,omitempty"`
 }
 type VoucherData struct {
 	Vouchers []Voucher `json:"vouchers,omitempty"`
 }
 
+type VoucherDetail struct {
+	Voucher
+	AuthorizedAccounts []Owner         `json:"authorizedAccounts,omitempty"`
+	Deals              []SponsoredDeal `json:"deals,omitempty"`
+}
+
+// SponsoredDeal is a deal paid, fully or partially, with a voucher
+type SponsoredDeal struct {
+	ID              string    `json:"id,omitempty"`
+	Timestamp       Timestamp `json:"timestamp,omitempty"`
+	SponsoredAmount Amount    `json:"sponsoredAmount,omitempty"`
+	App             Asset     `json:"app,omitempty"`
+	Dataset         Asset     `json:"dataset,omitempty"`
+	Workerpool      Asset     `json:"workerpool,omitempty"`
+}
+
+// VoucherTotals aggregate the value and the balance of a group of vouchers
+type VoucherTotals struct {
+	Count   int             `json:"count"`
+	Value   decimal.Decimal `json:"value"`
+	Balance decimal.Decimal `json:"balance"`
+}
+
+// VoucherStats aggregate vouchers by type ID, owner and expiration week (2006-W01) and month (2006-01), in UTC
+type VoucherStats struct {
+	Total   VoucherTotals            `json:"total"`
+	ByType  map[string]VoucherTotals `json:"byType"`
+	ByOwner map[string]VoucherTotals `json:"byOwner"`
+	ByWeek  map[string]VoucherTotals `json:"byWeek"`
+	ByMonth map[string]VoucherTotals `json:"byMonth"`
+	// Types are the descriptions of the voucher types by ID
+	Types map[string]string `json:"types"`
+}
+
+// VoucherFilter restricts the vouchers fetched from TheGraph, zero values are ignored
+type VoucherFilter struct {
+	Owner              string
+	VoucherType        string
+	ExpirationBefore   time.Time
+	ExpirationAfter    time.Time
+	MinBalance         *decimal.Decimal
+	MaxBalance         *decimal.Decimal
+	IncludeZeroBalance bool
+}
+
+// #endregion
+
+// #region PoCo struct
+type App struct {
+	ID        string    `json:"id,omitempty"`
+	Owner     Owner     `json:"owner,omitempty"`
+	Name      string    `json:"name,omitempty"`
+	Multiaddr Bytes     `json:"multiaddr,omitempty"`
+	Checksum  string    `json:"checksum,omitempty"`
+	Mrenclave Bytes     `json:"mrenclave,omitempty"`
+	Timestamp Timestamp `json:"timestamp,omitempty"`
+}
+
+type Dataset struct {
+	ID        string    `json:"id,omitempty"`
+	Owner     Owner     `json:"owner,omitempty"`
+	Name      string    `json:"name,omitempty"`
+	Multiaddr Bytes     `json:"multiaddr,omitempty"`
+	Checksum  string    `json:"checksum,omitempty"`
+	Timestamp Timestamp `json:"timestamp,omitempty"`
+}
+
+type Workerpool struct {
+	ID                   string    `json:"id,omitempty"`
+	Owner                Owner     `json:"owner,omitempty"`
+	Description          string    `json:"description,omitempty"`
+	WorkerStakeRatio     BigInt    `json:"workerStakeRatio,omitempty"`
+	SchedulerRewardRatio BigInt    `json:"schedulerRewardRatio,omitempty"`
+	Timestamp            Timestamp `json:"timestamp,omitempty"`
+}
+
+// AssetFilter restricts the apps, datasets or workerpools fetched from TheGraph, zero values are ignored
+type AssetFilter struct {
+	Owner string
+	// Name matches the name of apps and datasets, or the description of workerpools, ignoring case
+	Name string
+}
+
+type Category struct {
+	ID               string  `json:"id,omitempty"`
+	Name             string  `json:"name,omitempty"`
+	WorkClockTimeRef Seconds `json:"workClockTimeRef,omitempty"`
+}
+
+type Deal struct {
+	ID                  string     `json:"id,omitempty"`
+	Timestamp           Timestamp  `json:"timestamp,omitempty"`
+	StartTime           Timestamp  `json:"startTime,omitempty"`
+	App                 App        `json:"app,omitempty"`
+	Dataset             *Dataset   `json:"dataset,omitempty"`
+	Workerpool          Workerpool `json:"workerpool,omitempty"`
+	AppPrice            Amount     `json:"appPrice,omitempty"`
+	DatasetPrice        Amount     `json:"datasetPrice,omitempty"`
+	WorkerpoolPrice     Amount     `json:"workerpoolPrice,omitempty"`
+	Category            Category   `json:"category,omitempty"`
+	Trust               BigInt     `json:"trust,omitempty"`
+	Tag                 string     `json:"tag,omitempty"`
+	BotFirst            BigInt     `json:"botFirst,omitempty"`
+	BotSize             BigInt     `json:"botSize,omitempty"`
+	Requester           Owner      `json:"requester,omitempty"`
+	Beneficiary         Owner      `json:"beneficiary,omitempty"`
+	CompletedTasksCount BigInt     `json:"completedTasksCount,omitempty"`
+	ClaimedTasksCount   BigInt     `json:"claimedTasksCount,omitempty"`
+	Tasks               []Task     `json:"tasks,omitempty"`
+}
+
+type Task struct {
+	ID                   string         `json:"id,omitempty"`
+	Index                BigInt         `json:"index,omitempty"`
+	Status               string         `json:"status,omitempty"`
+	Deal                 *Deal          `json:"deal,omitempty"`
+	Timestamp            Timestamp      `json:"timestamp,omitempty"`
+	ContributionDeadline Timestamp      `json:"contributionDeadline,omitempty"`
+	RevealDeadline       Timestamp      `json:"revealDeadline,omitempty"`
+	FinalDeadline        Timestamp      `json:"finalDeadline,omitempty"`
+	Consensus            string         `json:"consensus,omitempty"`
+	ResultDigest         string         `json:"resultDigest,omitempty"`
+	Results              string         `json:"results,omitempty"`
+	Contributions        []Contribution `json:"contributions,omitempty"`
+}
+
+type Contribution struct {
+	ID        string    `json:"id,omitempty"`
+	Status    string    `json:"status,omitempty"`
+	Worker    Owner     `json:"worker,omitempty"`
+	Task      *Task     `json:"task,omitempty"`
+	Timestamp Timestamp `json:"timestamp,omitempty"`
+}
+
+// TaskFilter restricts the tasks fetched from TheGraph, zero values are ignored
+type TaskFilter struct {
+	Deal   string
+	Status string
+}
+
+type AppOrder struct {
+	ID                 string    `json:"id,omitempty"`
+	App                App       `json:"app,omitempty"`
+	AppPrice           Amount    `json:"appprice,omitempty"`
+	Volume             BigInt    `json:"volume,omitempty"`
+	Tag                string    `json:"tag,omitempty"`
+	DatasetRestrict    string    `json:"datasetrestrict,omitempty"`
+	WorkerpoolRestrict string    `json:"workerpoolrestrict,omitempty"`
+	RequesterRestrict  string    `json:"requesterrestrict,omitempty"`
+	Timestamp          Timestamp `json:"timestamp,omitempty"`
+	Deals              []Deal    `json:"deals,omitempty"`
+}
+
+type DatasetOrder struct {
+	ID                 string    `json:"id,omitempty"`
+	Dataset            Dataset   `json:"dataset,omitempty"`
+	DatasetPrice       Amount    `json:"datasetprice,omitempty"`
+	Volume             BigInt    `json:"volume,omitempty"`
+	Tag                string    `json:"tag,omitempty"`
+	AppRestrict        string    `json:"apprestrict,omitempty"`
+	WorkerpoolRestrict string    `json:"workerpoolrestrict,omitempty"`
+	RequesterRestrict  string    `json:"requesterrestrict,omitempty"`
+	Timestamp          Timestamp `json:"timestamp,omitempty"`
+	Deals              []Deal    `json:"deals,omitempty"`
+}
+
+type WorkerpoolOrder struct {
+	ID                string     `json:"id,omitempty"`
+	Workerpool        Workerpool `json:"workerpool,omitempty"`
+	WorkerpoolPrice   Amount     `json:"workerpoolprice,omitempty"`
+	Volume            BigInt     `json:"volume,omitempty"`
+	Tag               string     `json:"tag,omitempty"`
+	Category          Category   `json:"category,omitempty"`
+	Trust             BigInt     `json:"trust,omitempty"`
+	AppRestrict       string     `json:"apprestrict,omitempty"`
+	DatasetRestrict   string     `json:"datasetrestrict,omitempty"`
+	RequesterRestrict string     `json:"requesterrestrict,omitempty"`
+	Timestamp         Timestamp  `json:"timestamp,omitempty"`
+	Deals             []Deal     `json:"deals,omitempty"`
+}
+
+type RequestOrder struct {
+	ID                 string      `json:"id,omitempty"`
+	App                App         `json:"app,omitempty"`
+	AppMaxPrice        Amount      `json:"appmaxprice,omitempty"`
+	Dataset            *Dataset    `json:"dataset,omitempty"`
+	DatasetMaxPrice    Amount      `json:"datasetmaxprice,omitempty"`
+	Workerpool         *Workerpool `json:"workerpool,omitempty"`
+	WorkerpoolMaxPrice Amount      `json:"workerpoolmaxprice,omitempty"`
+	Requester          Owner       `json:"requester,omitempty"`
+	Beneficiary        Owner       `json:"beneficiary,omitempty"`
+	Volume             BigInt      `json:"volume,omitempty"`
+	Tag                string      `json:"tag,omitempty"`
+	Category           Category    `json:"category,omitempty"`
+	Trust              BigInt      `json:"trust,omitempty"`
+	Params             string      `json:"params,omitempty"`
+	Timestamp          Timestamp   `json:"timestamp,omitempty"`
+	Deals              []Deal      `json:"deals,omitempty"`
+}
+
+type Account struct {
+	ID      string `json:"id,omitempty"`
+	Balance Amount `json:"balance,omitempty"`
+	Frozen  Amount `json:"frozen,omitempty"`
+}
+
+type Transfer struct {
+	ID        string    `json:"id,omitempty"`
+	From      Owner     `json:"from,omitempty"`
+	To        Owner     `json:"to,omitempty"`
+	Value     Amount    `json:"value,omitempty"`
+	Timestamp Timestamp `json:"timestamp,omitempty"`
+}
+
+// AccountActivity gathers the PoCo and voucher activity of an account
+type AccountActivity struct {
+	Account        *Account       `json:"account,omitempty"`
+	RequestedDeals []Deal         `json:"requestedDeals,omitempty"`
+	ScheduledDeals []Deal         `json:"scheduledDeals,omitempty"`
+	Contributions  []Contribution `json:"contributions,omitempty"`
+	Apps           []App          `json:"apps,omitempty"`
+	Datasets       []Dataset      `json:"datasets,omitempty"`
+	Workerpools    []Workerpool   `json:"workerpools,omitempty"`
+	Transfers      []Transfer     `json:"transfers,omitempty"`
+	Vouchers       []Voucher      `json:"vouchers,omitempty"`
+}
+
+// WorkerpoolOrderFilter restricts the workerpool orders fetched from TheGraph, zero values are ignored
+type WorkerpoolOrderFilter struct {
+	Category   string
+	Workerpool string
+	MaxPrice   *decimal.Decimal
+}
+
+// RequestOrderFilter restricts the request orders fetched from TheGraph, zero values are ignored
+type RequestOrderFilter struct {
+	Requester string
+	App       string
+	Category  string
+}
+
+// DealFilter restricts the deals fetched from TheGraph, zero values are ignored
+type DealFilter struct {
+	Requester  string
+	App        string
+	Dataset    string
+	Workerpool string
+	After      time.Time
+	Before     time.Time
+}
+
+// #endregion
+
+// #region Schema struct
+type Schema struct {
+	QueryType TypeName     `json:"queryType"`
+	Types     []SchemaType `json:"types"`
+}
+
+type TypeName struct {
+	Name string `json:"name"`
+}
+
+type SchemaType struct {
+	Kind        string        `json:"kind"`
+	Name        string        `json:"name"`
+	Description string        `json:"description,omitempty"`
+	Fields      []SchemaField `json:"fields,omitempty"`
+	InputFields []InputValue  `json:"inputFields,omitempty"`
+	EnumValues  []TypeName    `json:"enumValues,omitempty"`
+}
+
+type SchemaField struct {
+	Name        string       `json:"name"`
+	Description string       `json:"description,omitempty"`
+	Args        []InputValue `json:"args,omitempty"`
+	Type        TypeRef      `json:"type"`
+}
+
+type InputValue struct {
+	Name string  `json:"name"`
+	Type TypeRef `json:"type"`
+}
+
+// TypeRef is a possibly wrapped (NON_NULL, LIST) reference to a named type
+type TypeRef struct {
+	Kind   string   `json:"kind"`
+	Name   string   `json:"name,omitempty"`
+	OfType *TypeRef `json:"ofType,omitempty"`
+}
+
+// Entity is a queryable entity of a subgraph, with the root fields fetching it
+type Entity struct {
+	Name        string
+	Description string
+	Collection  string
+	Single      string
+	Fields      []EntityField
+	// Filters maps a field to its where operators, "eq" is equality and "nested" filters on the related entity
+	Filters map[string][]string
+	OrderBy []string
+
+	// filterType is the name of the where filter input type
+	filterType string
+}
+
+// EntityField is a field of an entity, Relation is the related entity type, if any
+type EntityField struct {
+	Name     string
+	Type     string
+	Relation string
+}
+
 // #endregion
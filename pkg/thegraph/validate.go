@@ -0,0 +1,286 @@
+package thegraph
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+)
+
+const (
+	// defaultFirst is the number of entities TheGraph returns for a list without a first argument
+	defaultFirst = 100
+	// maxEstimate saturates the estimated number of entities so that it can not overflow
+	maxEstimate = math.MaxInt32
+)
+
+var errRejectedQuery = errors.New("GraphQL query rejected")
+
+// QueryLimits bounds the GraphQL documents accepted from users, a zero limit is not enforced
+type QueryLimits struct {
+	// MaxFirst is the highest first argument of a list
+	MaxFirst int
+	// MaxDepth is the deepest nesting of selection sets
+	MaxDepth int
+	// MaxResults is the highest estimated number of entities in the response
+	MaxResults int
+	// Timeout bounds the execution of each query, retries included
+	Timeout time.Duration
+}
+
+// DefaultQueryLimits are the limits of a new client
+var DefaultQueryLimits = QueryLimits{
+	MaxFirst:   maxPageSize,
+	MaxDepth:   8,
+	MaxResults: 10000,
+	Timeout:    30 * time.Second,
+}
+
+// WithQueryLimits sets the limits of the GraphQL documents run by SafeQuery
+func WithQueryLimits(limits QueryLimits) Option {
+	return func(c *Client) {
+		c.queryLimits = limits
+	}
+}
+
+// QueryLimits returns the limits of the GraphQL documents run by SafeQuery
+func (c *Client) QueryLimits() QueryLimits {
+	return c.queryLimits
+}
+
+// SafeQuery runs a GraphQL document supplied by a user: it must be a single read-only query within the
+// client QueryLimits and is aborted after the limits timeout. The subgraph schema is used to tell lists
+// from single entities when estimating the result size
+func (c *Client) SafeQuery(ctx context.Context, endpoint, query string, variables map[string]interface{}) (json.RawMessage, error) {
+	if c.queryLimits.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.queryLimits.Timeout)
+		defer cancel()
+	}
+
+	// Without the schema the size estimation falls back to the list arguments
+	var schema *Schema
+	if s, err := c.GetSchema(ctx, endpoint); err == nil {
+		schema = &s
+	}
+
+	if err := ValidateQuery(query, variables, c.queryLimits, schema); err != nil {
+		return nil, err
+	}
+
+	return c.Query(ctx, endpoint, query, variables)
+}
+
+// ValidateQuery checks that a GraphQL document is a single read-only query within limits. The schema is optional,
+// without it a field is considered a list when it has a first, skip, where or orderBy argument
+func ValidateQuery(query string, variables map[string]interface{}, limits QueryLimits, schema *Schema) error {
+	doc, err := parseDocument(query)
+	if err != nil {
+		return fmt.Errorf("%w: %v", errRejectedQuery, err)
+	}
+
+	if len(doc.operations) != 1 {
+		return fmt.Errorf("%w: the document must contain exactly one operation, got %d", errRejectedQuery, len(doc.operations))
+	}
+
+	op := doc.operations[0]
+	if op.kind != "query" {
+		return fmt.Errorf("%w: only queries are allowed, got a %s", errRejectedQuery, op.kind)
+	}
+
+	v := validator{doc: doc, op: op, variables: variables, limits: limits, schema: schema}
+
+	rootType := ""
+	if schema != nil {
+		rootType = schema.QueryType.Name
+	}
+
+	results, err := v.selections(op.selections, rootType, 1, 1, nil)
+	if err != nil {
+		return err
+	}
+
+	if limits.MaxResults > 0 && results > limits.MaxResults {
+		return fmt.Errorf("%w: the query may return up to %d entities, more than the limit of %d, lower the first arguments",
+			errRejectedQuery, results, limits.MaxResults)
+	}
+
+	return nil
+}
+
+// validator walks the selections of an operation
+type validator struct {
+	doc       document
+	op        operation
+	variables map[string]interface{}
+	limits    QueryLimits
+	schema    *Schema
+}
+
+// selections checks a selection set at depth, fetched multiplier times, and returns its estimated number of entities.
+// fragments lists the fragments being expanded to detect cycles
+func (v validator) selections(selections []selection, typeName string, depth, multiplier int, fragments []string) (int, error) {
+	if v.limits.MaxDepth > 0 && depth > v.limits.MaxDepth {
+		return 0, fmt.Errorf("%w: the query is nested deeper than the limit of %d", errRejectedQuery, v.limits.MaxDepth)
+	}
+
+	results := 0
+
+	for _, sel := range selections {
+		var count int
+		var err error
+
+		switch {
+		case sel.spread != "":
+			count, err = v.spread(sel.spread, depth, multiplier, fragments)
+		case sel.inline:
+			inlineType := typeName
+			if sel.typeCondition != "" {
+				inlineType = sel.typeCondition
+			}
+			count, err = v.selections(sel.selections, inlineType, depth, multiplier, fragments)
+		default:
+			count, err = v.field(sel, typeName, depth, multiplier, fragments)
+		}
+
+		if err != nil {
+			return 0, err
+		}
+
+		results = saturatedAdd(results, count)
+		if v.limits.MaxResults > 0 && results > v.limits.MaxResults {
+			// No need to walk further, the estimation is already over the limit
+			return results, nil
+		}
+	}
+
+	return results, nil
+}
+
+func (v validator) spread(name string, depth, multiplier int, fragments []string) (int, error) {
+	for _, expanding := range fragments {
+		if expanding == name {
+			return 0, fmt.Errorf("%w: fragment %s spreads itself", errRejectedQuery, name)
+		}
+	}
+
+	frag, found := v.doc.fragments[name]
+	if !found {
+		return 0, fmt.Errorf("%w: unknown fragment %s", errRejectedQuery, name)
+	}
+
+	return v.selections(frag.selections, frag.typeCondition, depth, multiplier, append(fragments, name))
+}
+
+// field checks the arguments and the selections of a field and returns its estimated number of entities
+func (v validator) field(sel selection, typeName string, depth, multiplier int, fragments []string) (int, error) {
+	if sel.selections == nil {
+		return 0, nil
+	}
+
+	first, err := v.first(sel)
+	if err != nil {
+		return 0, err
+	}
+
+	list, fieldType := v.fieldType(sel, typeName)
+	if list {
+		if first < 0 {
+			first = defaultFirst
+		}
+		multiplier = saturatedMul(multiplier, first)
+	}
+
+	if v.limits.MaxResults > 0 && multiplier > v.limits.MaxResults {
+		// Already over the limit, nested selections can only add to it
+		return multiplier, nil
+	}
+
+	nested, err := v.selections(sel.selections, fieldType, depth+1, multiplier, fragments)
+	if err != nil {
+		return 0, err
+	}
+
+	return saturatedAdd(multiplier, nested), nil
+}
+
+// first returns the first argument of a field, -1 when the field has none
+func (v validator) first(sel selection) (int, error) {
+	arg, found := sel.arguments["first"]
+	if !found {
+		return -1, nil
+	}
+
+	raw := arg.raw
+	if arg.kind == valueVariable {
+		variable, found := v.variables[arg.raw]
+		if !found {
+			defaultValue, hasDefault := v.op.defaults[arg.raw]
+			if !hasDefault {
+				return -1, nil
+			}
+			raw = defaultValue.raw
+		} else {
+			raw = fmt.Sprint(variable)
+		}
+	}
+
+	first, err := strconv.Atoi(raw)
+	if err != nil {
+		if f, floatErr := strconv.ParseFloat(raw, 64); floatErr == nil && f == float64(int(f)) {
+			first, err = int(f), nil
+		}
+	}
+
+	if err != nil || first < 0 {
+		return 0, fmt.Errorf("%w: invalid first argument %s on %s", errRejectedQuery, raw, sel.name)
+	}
+
+	if v.limits.MaxFirst > 0 && first > v.limits.MaxFirst {
+		return 0, fmt.Errorf("%w: first argument %d on %s is over the limit of %d", errRejectedQuery, first, sel.name, v.limits.MaxFirst)
+	}
+
+	return first, nil
+}
+
+// fieldType tells whether a field is a list and returns the name of its type, empty when unknown
+func (v validator) fieldType(sel selection, typeName string) (bool, string) {
+	if v.schema != nil {
+		if t, found := v.schema.Type(typeName); found {
+			for _, f := range t.Fields {
+				if f.Name == sel.name {
+					return f.Type.unwrap().Kind == "LIST", f.Type.Named()
+				}
+			}
+		}
+	}
+
+	for _, arg := range []string{"first", "skip", "where", "orderBy"} {
+		if _, found := sel.arguments[arg]; found {
+			return true, ""
+		}
+	}
+
+	return false, ""
+}
+
+// saturatedAdd adds two estimations of at most maxEstimate without exceeding it
+func saturatedAdd(a, b int) int {
+	if b > maxEstimate-a {
+		return maxEstimate
+	}
+
+	return a + b
+}
+
+// saturatedMul multiplies an estimation of at most maxEstimate by a non negative factor without exceeding it
+func saturatedMul(a, b int) int {
+	if b > 0 && a > maxEstimate/b {
+		return maxEstimate
+	}
+
+	return a * b
+}
@@ -0,0 +1,236 @@
+package thegraph
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestValidateQuery(t *testing.T) {
+	limits := QueryLimits{MaxFirst: 1000, MaxDepth: 3, MaxResults: 5000}
+
+	tests := []struct {
+		name      string
+		query     string
+		variables map[string]interface{}
+		wantErr   string
+	}{
+		{
+			name:  "shorthand query",
+			query: `{ vouchers(first: 10) { id owner { id } } }`,
+		},
+		{
+			name:  "named query with variables and default",
+			query: `query q($first: Int = 500, $owner: String!) { vouchers(first: $first, where: {owner: $owner}) { id } }`,
+			variables: map[string]interface{}{
+				"owner": "0x1",
+			},
+		},
+		{
+			name:      "first given as a JSON number",
+			query:     `query q($first: Int!) { vouchers(first: $first) { id } }`,
+			variables: map[string]interface{}{"first": float64(1000)},
+		},
+		{
+			name:  "fragments and aliases",
+			query: `{ a: deals(first: 5) { ...deal } b: deals(first: 5) { ...deal } } fragment deal on Deal { id app { id } }`,
+		},
+		{
+			name:  "meta and introspection",
+			query: `{ _meta { block { number } } __typename }`,
+		},
+		{
+			name:    "mutation",
+			query:   `mutation { createVoucher(id: "1") { id } }`,
+			wantErr: "only queries are allowed, got a mutation",
+		},
+		{
+			name:    "subscription",
+			query:   `subscription { vouchers { id } }`,
+			wantErr: "only queries are allowed, got a subscription",
+		},
+		{
+			name:    "several operations",
+			query:   `query a { vouchers { id } } query b { deals { id } }`,
+			wantErr: "exactly one operation",
+		},
+		{
+			name:    "syntax error",
+			query:   `{ vouchers(first: 10 { id } }`,
+			wantErr: "invalid GraphQL document",
+		},
+		{
+			name:    "empty document",
+			query:   "  # nothing\n",
+			wantErr: "empty document",
+		},
+		{
+			name:    "first over the limit",
+			query:   `{ vouchers(first: 1001) { id } }`,
+			wantErr: "first argument 1001 on vouchers is over the limit of 1000",
+		},
+		{
+			name:      "first variable over the limit",
+			query:     `query q($first: Int!) { vouchers(first: $first) { id } }`,
+			variables: map[string]interface{}{"first": 5000},
+			wantErr:   "over the limit of 1000",
+		},
+		{
+			name:    "negative first",
+			query:   `{ vouchers(first: -1) { id } }`,
+			wantErr: "invalid first argument -1",
+		},
+		{
+			name:    "too deep",
+			query:   `{ deals(first: 1) { tasks(first: 1) { contributions(first: 1) { worker { id } } } } }`,
+			wantErr: "nested deeper than the limit of 3",
+		},
+		{
+			name:    "too deep through a fragment",
+			query:   `{ deals(first: 1) { ...tasks } } fragment tasks on Deal { tasks(first: 1) { contributions(first: 1) { worker { id } } } }`,
+			wantErr: "nested deeper than the limit of 3",
+		},
+		{
+			name:    "too many results",
+			query:   `{ deals(first: 1000) { tasks(first: 10) { id } } }`,
+			wantErr: "may return up to 11000 entities",
+		},
+		{
+			name:    "too many results with default first",
+			query:   `{ deals(where: {app: "0x1"}) { tasks(orderBy: id) { id } } }`,
+			wantErr: "may return up to 10100 entities",
+		},
+		{
+			name:    "fragment cycle",
+			query:   `{ deals(first: 1) { ...a } } fragment a on Deal { ...b } fragment b on Deal { ...a }`,
+			wantErr: "spreads itself",
+		},
+		{
+			name:    "unknown fragment",
+			query:   `{ deals(first: 1) { ...missing } }`,
+			wantErr: "unknown fragment missing",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateQuery(tt.query, tt.variables, limits, nil)
+
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("expected the query to be accepted, got %v", err)
+				}
+
+				return
+			}
+
+			if !errors.Is(err, errRejectedQuery) || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("expected an error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestValidateQueryBounds(t *testing.T) {
+	// The estimation saturates instead of wrapping around to a negative number of entities
+	query := `{ deals(first: 2) { tasks(first: 9223372036854775807) { id } } }`
+	if err := ValidateQuery(query, nil, QueryLimits{MaxResults: 10000}, nil); !errors.Is(err, errRejectedQuery) {
+		t.Errorf("expected the query to be rejected, got %v", err)
+	}
+
+	for _, query := range []string{
+		strings.Repeat("{ a ", 10000) + strings.Repeat("}", 10000),
+		`{ deals(where: ` + strings.Repeat("[", 10000) + strings.Repeat("]", 10000) + `) { id } }`,
+		`query q($a: ` + strings.Repeat("[", 10000) + "Int" + strings.Repeat("]", 10000) + `) { deals { id } }`,
+	} {
+		if err := ValidateQuery(query, nil, QueryLimits{}, nil); !errors.Is(err, errRejectedQuery) || !strings.Contains(err.Error(), "nested deeper than") {
+			t.Errorf("expected a deeply nested document to be rejected, got %v", err)
+		}
+	}
+}
+
+func TestValidateQueryWithSchema(t *testing.T) {
+	var response struct {
+		Data struct {
+			Schema Schema `json:"__schema"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal([]byte(mockSchemaResponse), &response); err != nil {
+		t.Fatal(err)
+	}
+	schema := &response.Data.Schema
+
+	limits := QueryLimits{MaxResults: 150}
+
+	// Without arguments vouchers is only known to be a list, fetched 100 times by default, from the schema
+	query := `{ vouchers { id owner { id } } more: vouchers { id } }`
+
+	if err := ValidateQuery(query, nil, limits, nil); err != nil {
+		t.Errorf("expected the query to be accepted without the schema, got %v", err)
+	}
+
+	if err := ValidateQuery(query, nil, limits, schema); !errors.Is(err, errRejectedQuery) {
+		t.Errorf("expected the query to be rejected with the schema, got %v", err)
+	}
+
+	if err := ValidateQuery(`{ vouchers { id } }`, nil, limits, schema); err != nil {
+		t.Errorf("expected the query to be accepted, got %v", err)
+	}
+}
+
+func TestSafeQuery(t *testing.T) {
+	var queries []string
+
+	transport := &MockRoundTripper{RoundTripFunc: func(req *http.Request) (*http.Response, error) {
+		var payload graphQLRequest
+		if err := json.NewDecoder(req.Body).Decode(&payload); err != nil {
+			t.Fatalf("failed to decode request body: %v", err)
+		}
+		queries = append(queries, payload.Query)
+
+		if strings.Contains(payload.Query, "__schema") {
+			return newStatusResponse(200, mockSchemaResponse), nil
+		}
+
+		return newStatusResponse(200, `{"data": {"vouchers": []}}`), nil
+	}}
+
+	client := NewClient("http://mocked", WithTransport(transport), WithQueryLimits(QueryLimits{MaxFirst: 10}))
+
+	if _, err := client.SafeQuery(context.Background(), VoucherSubgraph, `{ vouchers(first: 10) { id } }`, nil); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if _, err := client.SafeQuery(context.Background(), VoucherSubgraph, `{ vouchers(first: 11) { id } }`, nil); !errors.Is(err, errRejectedQuery) {
+		t.Errorf("expected the query to be rejected, got %v", err)
+	}
+
+	if len(queries) != 2 {
+		t.Errorf("expected the schema and the accepted query to be sent, got %v", queries)
+	}
+}
+
+func TestSafeQueryTimeout(t *testing.T) {
+	transport := &MockRoundTripper{RoundTripFunc: func(req *http.Request) (*http.Response, error) {
+		<-req.Context().Done()
+		return nil, req.Context().Err()
+	}}
+
+	client := NewClient("http://mocked", WithTransport(transport), WithoutRetry(),
+		WithQueryLimits(QueryLimits{Timeout: 50 * time.Millisecond}))
+
+	start := time.Now()
+	_, err := client.SafeQuery(context.Background(), VoucherSubgraph, `{ vouchers { id } }`, nil)
+
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected a deadline exceeded error, got %v", err)
+	}
+
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("expected the query to be aborted after the timeout, took %s", elapsed)
+	}
+}
@@ -0,0 +1,251 @@
+package thegraph
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"iter"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// VoucherEndpoint is the path of the iExec voucher subgraph under the TheGraph URL
+const VoucherEndpoint = "/iexec-voucher"
+
+var errVoucherNotFound = errors.New("voucher not found")
+
+var voucherCollection = collection{
+	endpoint:   VoucherSubgraph,
+	name:       "vouchers",
+	filterType: "Voucher_filter",
+	fields: `
+			voucherType {
+				id
+				description
+			}
+			id
+			owner {
+				id
+			}
+			expiration
+			value
+			balance
+		`,
+}
+
+// GetVouchers fetches all vouchers matching filter from TheGraph
+func (c *Client) GetVouchers(ctx context.Context, filter VoucherFilter) (VoucherResponse, error) {
+	var vouchers VoucherResponse
+
+	for voucher, err := range c.Vouchers(ctx, filter) {
+		if err != nil {
+			return vouchers, err
+		}
+		vouchers.Data.Vouchers = append(vouchers.Data.Vouchers, voucher)
+	}
+
+	return vouchers, nil
+}
+
+// GetVouchersPage fetches at most limit vouchers matching filter with an ID greater than cursor,
+// NextCursor of the returned page is empty when no more vouchers remain
+func (c *Client) GetVouchersPage(ctx context.Context, filter VoucherFilter, cursor string, limit int) (Page[Voucher], error) {
+	return fetchPage[Voucher](ctx, c, voucherCollection, filter.where(), cursor, limit)
+}
+
+// Vouchers streams all vouchers matching filter, walking the pages by ID
+func (c *Client) Vouchers(ctx context.Context, filter VoucherFilter) iter.Seq2[Voucher, error] {
+	return walk[Voucher](ctx, c, voucherCollection, filter.where())
+}
+
+// GetVoucher fetches a single voucher with its type eligibility, authorized accounts and consumption history
+func (c *Client) GetVoucher(ctx context.Context, id string) (VoucherDetail, error) {
+	fields := `
+			voucherType {
+				id
+				description
+				duration
+				eligibleAssets {
+					id
+					assetType
+				}
+			}
+			id
+			owner {
+				id
+			}
+			expiration
+			value
+			balance
+			authorizedAccounts {
+				id
+			}
+			deals(orderBy: timestamp, orderDirection: desc, first: 100) {
+				id
+				timestamp
+				sponsoredAmount
+				app {
+					id
+				}
+				dataset {
+					id
+				}
+				workerpool {
+					id
+				}
+			}
+		`
+
+	voucher, found, err := fetchByID[VoucherDetail](ctx, c, VoucherSubgraph, "voucher", fields, id)
+	if err == nil && !found {
+		err = errVoucherNotFound
+	}
+
+	return voucher, err
+}
+
+// GetVoucherTypes fetches all voucher types with their eligible assets. Counting the active vouchers of
+// each type walks all of them, so it is only done when countActive is set
+func (c *Client) GetVoucherTypes(ctx context.Context, countActive bool) ([]VoucherTypeSummary, error) {
+	query := `
+	{
+		voucherTypes(orderBy: id, orderDirection: asc, first: 1000) {
+			id
+			description
+			duration
+			eligibleAssets {
+				id
+				assetType
+			}
+		}
+	}`
+
+	var response VoucherTypeResponse
+	if err := c.fetchGraphQLData(ctx, VoucherSubgraph, query, nil, &response); err != nil {
+		return nil, err
+	}
+
+	voucherTypes := make([]VoucherTypeSummary, 0, len(response.Data.VoucherTypes))
+	for _, voucherType := range response.Data.VoucherTypes {
+		voucherTypes = append(voucherTypes, VoucherTypeSummary{VoucherType: voucherType})
+	}
+
+	if !countActive {
+		return voucherTypes, nil
+	}
+
+	// Count vouchers with a positive balance which are not expired yet
+	active := map[string]int{}
+	for voucher, err := range c.Vouchers(ctx, VoucherFilter{ExpirationAfter: time.Now()}) {
+		if err != nil {
+			return nil, err
+		}
+		active[voucher.VoucherType.ID]++
+	}
+
+	for i := range voucherTypes {
+		count := active[voucherTypes[i].ID]
+		voucherTypes[i].ActiveVouchers = &count
+	}
+
+	return voucherTypes, nil
+}
+
+// GetVoucherStats walks all vouchers matching filter and aggregates their value and balance
+func (c *Client) GetVoucherStats(ctx context.Context, filter VoucherFilter) (VoucherStats, error) {
+	stats := VoucherStats{
+		ByType:  make(map[string]VoucherTotals),
+		ByOwner: make(map[string]VoucherTotals),
+		ByWeek:  make(map[string]VoucherTotals),
+		ByMonth: make(map[string]VoucherTotals),
+		Types:   make(map[string]string),
+	}
+
+	for voucher, err := range c.Vouchers(ctx, filter) {
+		if err != nil {
+			return stats, err
+		}
+		stats.add(voucher)
+	}
+
+	return stats, nil
+}
+
+// add counts a voucher in the total and in each of its groups
+func (s *VoucherStats) add(v Voucher) {
+	expiration := v.Expiration.UTC()
+	year, number := expiration.ISOWeek()
+	week := fmt.Sprintf("%d-W%02d", year, number)
+	month := expiration.Format("2006-01")
+
+	s.Total = s.Total.add(v)
+	s.ByType[v.VoucherType.ID] = s.ByType[v.VoucherType.ID].add(v)
+	s.ByOwner[v.Owner.ID] = s.ByOwner[v.Owner.ID].add(v)
+	s.ByWeek[week] = s.ByWeek[week].add(v)
+	s.ByMonth[month] = s.ByMonth[month].add(v)
+	s.Types[v.VoucherType.ID] = v.VoucherType.Desc
+}
+
+func (t VoucherTotals) add(v Voucher) VoucherTotals {
+	return VoucherTotals{
+		Count:   t.Count + 1,
+		Value:   t.Value.Add(v.Value.Decimal),
+		Balance: t.Balance.Add(v.Balance.Decimal),
+	}
+}
+
+// Consumed is the part of the value already spent
+func (t VoucherTotals) Consumed() decimal.Decimal {
+	return t.Value.Sub(t.Balance)
+}
+
+// Utilisation is the consumed part of the value as a ratio between 0 and 1, 0 when there is no value
+func (t VoucherTotals) Utilisation() decimal.Decimal {
+	if !t.Value.IsPositive() {
+		return decimal.Zero
+	}
+
+	return t.Consumed().Div(t.Value)
+}
+
+// where translates the filter into a GraphQL Voucher_filter input
+func (f VoucherFilter) where() map[string]interface{} {
+	where := map[string]interface{}{}
+
+	if f.Owner != "" {
+		where["owner"] = strings.ToLower(f.Owner)
+	}
+
+	if f.VoucherType != "" {
+		where["voucherType"] = f.VoucherType
+	}
+
+	if !f.ExpirationBefore.IsZero() {
+		where["expiration_lt"] = strconv.FormatInt(f.ExpirationBefore.Unix(), 10)
+	}
+
+	if !f.ExpirationAfter.IsZero() {
+		where["expiration_gt"] = strconv.FormatInt(f.ExpirationAfter.Unix(), 10)
+	}
+
+	if f.MinBalance != nil {
+		where["balance_gte"] = f.MinBalance.String()
+	}
+
+	if f.MaxBalance != nil {
+		where["balance_lte"] = f.MaxBalance.String()
+	}
+
+	if !f.IncludeZeroBalance {
+		where["balance_gt"] = "0"
+	}
+
+	return where
+}
+
+func (v Voucher) entityID() string {
+	return v.ID
+}
@@ -0,0 +1,376 @@
+package thegraph
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// newVoucherPageResponse builds a vouchers response with ids from start to start+count-1
+func newVoucherPageResponse(start, count int) string {
+	vouchers := make([]Voucher, 0, count)
+	for i := start; i < start+count; i++ {
+		vouchers = append(vouchers, Voucher{ID: fmt.Sprintf("%04d", i)})
+	}
+
+	data, _ := json.Marshal(VoucherResponse{Data: VoucherData{Vouchers: vouchers}})
+
+	return string(data)
+}
+
+func TestGetVouchersPage(t *testing.T) {
+	var payload graphQLRequest
+
+	client := newMockedClient(func(req *http.Request) (*http.Response, error) {
+		if err := json.NewDecoder(req.Body).Decode(&payload); err != nil {
+			t.Fatalf("failed to decode request body: %v", err)
+		}
+
+		return &http.Response{
+			StatusCode: 200,
+			Body:       io.NopCloser(bytes.NewBufferString(newVoucherPageResponse(11, 3))),
+			Header:     make(http.Header),
+		}, nil
+	})
+
+	page, err := client.GetVouchersPage(context.Background(), VoucherFilter{}, "0010", 2)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if payload.Variables["first"] != float64(3) {
+		t.Errorf("expected first 3, got %v", payload.Variables["first"])
+	}
+
+	where, _ := payload.Variables["where"].(map[string]interface{})
+	if where["id_gt"] != "0010" {
+		t.Errorf("expected id_gt '0010', got %v", where["id_gt"])
+	}
+
+	if len(page.Items) != 2 {
+		t.Fatalf("expected 2 vouchers, got %d", len(page.Items))
+	}
+
+	if page.NextCursor != "0012" {
+		t.Errorf("expected next cursor '0012', got '%s'", page.NextCursor)
+	}
+}
+
+func TestGetVouchersPageLast(t *testing.T) {
+	client := newMockedClient(func(req *http.Request) (*http.Response, error) {
+		return &http.Response{
+			StatusCode: 200,
+			Body:       io.NopCloser(bytes.NewBufferString(newVoucherPageResponse(0, 2))),
+			Header:     make(http.Header),
+		}, nil
+	})
+
+	page, err := client.GetVouchersPage(context.Background(), VoucherFilter{}, "", 5)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if len(page.Items) != 2 || page.NextCursor != "" {
+		t.Errorf("expected 2 vouchers without next cursor, got %d and '%s'", len(page.Items), page.NextCursor)
+	}
+}
+
+func TestGetVouchersWalksAllPages(t *testing.T) {
+	calls := 0
+
+	client := newMockedClient(func(req *http.Request) (*http.Response, error) {
+		calls++
+
+		count := maxPageSize
+		if calls == 2 {
+			count = 10
+		}
+
+		return &http.Response{
+			StatusCode: 200,
+			Body:       io.NopCloser(bytes.NewBufferString(newVoucherPageResponse((calls-1)*maxPageSize, count))),
+			Header:     make(http.Header),
+		}, nil
+	})
+
+	vouchers, err := client.GetVouchers(context.Background(), VoucherFilter{})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if calls != 2 {
+		t.Errorf("expected 2 requests, got %d", calls)
+	}
+
+	if len(vouchers.Data.Vouchers) != maxPageSize+10 {
+		t.Errorf("expected %d vouchers, got %d", maxPageSize+10, len(vouchers.Data.Vouchers))
+	}
+}
+
+func TestVoucherFilterWhere(t *testing.T) {
+	minBalance := decimal.RequireFromString("1.5")
+	filter := VoucherFilter{
+		Owner:            "0xABCDEF",
+		VoucherType:      "2",
+		ExpirationBefore: time.Unix(2000, 0),
+		ExpirationAfter:  time.Unix(1000, 0),
+		MinBalance:       &minBalance,
+	}
+
+	expected := map[string]interface{}{
+		"owner":         "0xabcdef",
+		"voucherType":   "2",
+		"expiration_lt": "2000",
+		"expiration_gt": "1000",
+		"balance_gte":   "1.5",
+		"balance_gt":    "0",
+	}
+
+	where := filter.where()
+	if len(where) != len(expected) {
+		t.Errorf("expected %d conditions, got %v", len(expected), where)
+	}
+
+	for key, value := range expected {
+		if where[key] != value {
+			t.Errorf("expected %s=%v, got %v", key, value, where[key])
+		}
+	}
+
+	filter = VoucherFilter{IncludeZeroBalance: true}
+	if where := filter.where(); len(where) != 0 {
+		t.Errorf("expected no condition, got %v", where)
+	}
+}
+
+func TestGetVouchersSendsFilter(t *testing.T) {
+	var payload graphQLRequest
+
+	client := newMockedClient(func(req *http.Request) (*http.Response, error) {
+		if err := json.NewDecoder(req.Body).Decode(&payload); err != nil {
+			t.Fatalf("failed to decode request body: %v", err)
+		}
+
+		return &http.Response{
+			StatusCode: 200,
+			Body:       io.NopCloser(bytes.NewBufferString(newVoucherPageResponse(0, 1))),
+			Header:     make(http.Header),
+		}, nil
+	})
+
+	_, err := client.GetVouchers(context.Background(), VoucherFilter{Owner: "0xOwner"})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	where, _ := payload.Variables["where"].(map[string]interface{})
+	if where["owner"] != "0xowner" {
+		t.Errorf("expected owner '0xowner', got %v", where["owner"])
+	}
+}
+
+func TestGetVoucher(t *testing.T) {
+	mockResponse := `{
+		"data": {
+			"voucher": {
+				"id": "0xvoucher",
+				"voucherType": {
+					"id": "1",
+					"description": "Early adopter",
+					"duration": "2592000",
+					"eligibleAssets": [{"id": "0xworkerpool", "assetType": "workerpool"}]
+				},
+				"owner": {"id": "0xowner"},
+				"expiration": "1735689600",
+				"value": "100",
+				"balance": "42.5",
+				"authorizedAccounts": [{"id": "0xfriend"}],
+				"deals": [
+					{
+						"id": "0xdeal",
+						"timestamp": "1733000000",
+						"sponsoredAmount": "57.5",
+						"app": {"id": "0xapp"},
+						"workerpool": {"id": "0xworkerpool"}
+					}
+				]
+			}
+		}
+	}`
+
+	var payload graphQLRequest
+
+	client := newMockedClient(func(req *http.Request) (*http.Response, error) {
+		if err := json.NewDecoder(req.Body).Decode(&payload); err != nil {
+			t.Fatalf("failed to decode request body: %v", err)
+		}
+
+		return &http.Response{
+			StatusCode: 200,
+			Body:       io.NopCloser(bytes.NewBufferString(mockResponse)),
+			Header:     make(http.Header),
+		}, nil
+	})
+
+	voucher, err := client.GetVoucher(context.Background(), "0xVOUCHER")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if payload.Variables["id"] != "0xvoucher" {
+		t.Errorf("expected id '0xvoucher', got %v", payload.Variables["id"])
+	}
+
+	if voucher.ID != "0xvoucher" || voucher.Balance.String() != "42.5" {
+		t.Errorf("unexpected voucher %+v", voucher.Voucher)
+	}
+
+	if voucher.VoucherType.Duration.Hours() != 720 || len(voucher.VoucherType.EligibleAssets) != 1 {
+		t.Errorf("unexpected voucher type %+v", voucher.VoucherType)
+	}
+
+	if len(voucher.AuthorizedAccounts) != 1 || len(voucher.Deals) != 1 {
+		t.Fatalf("expected 1 authorized account and 1 deal, got %+v", voucher)
+	}
+
+	if voucher.Deals[0].SponsoredAmount.String() != "57.5" || voucher.Deals[0].App.ID != "0xapp" {
+		t.Errorf("unexpected deal %+v", voucher.Deals[0])
+	}
+}
+
+func TestGetVoucherNotFound(t *testing.T) {
+	client := newMockedClient(func(req *http.Request) (*http.Response, error) {
+		return &http.Response{
+			StatusCode: 200,
+			Body:       io.NopCloser(bytes.NewBufferString(`{"data": {"voucher": null}}`)),
+			Header:     make(http.Header),
+		}, nil
+	})
+
+	_, err := client.GetVoucher(context.Background(), "0xunknown")
+	if !errors.Is(err, errVoucherNotFound) {
+		t.Errorf("expected errVoucherNotFound, got %v", err)
+	}
+}
+
+func TestGetVoucherTypes(t *testing.T) {
+	typesResponse := `{
+		"data": {
+			"voucherTypes": [
+				{"id": "1", "description": "Early adopter", "duration": "2592000", "eligibleAssets": [{"id": "0xpool", "assetType": "workerpool"}]},
+				{"id": "2", "description": "Hackathon", "duration": "604800"}
+			]
+		}
+	}`
+	vouchersResponse := `{
+		"data": {
+			"vouchers": [
+				{"id": "0x1", "voucherType": {"id": "1"}},
+				{"id": "0x2", "voucherType": {"id": "1"}}
+			]
+		}
+	}`
+
+	var vouchersWhere map[string]interface{}
+
+	client := newMockedClient(func(req *http.Request) (*http.Response, error) {
+		var payload graphQLRequest
+		if err := json.NewDecoder(req.Body).Decode(&payload); err != nil {
+			t.Fatalf("failed to decode request body: %v", err)
+		}
+
+		body := typesResponse
+		if payload.Variables != nil {
+			vouchersWhere, _ = payload.Variables["where"].(map[string]interface{})
+			body = vouchersResponse
+		}
+
+		return &http.Response{
+			StatusCode: 200,
+			Body:       io.NopCloser(bytes.NewBufferString(body)),
+			Header:     make(http.Header),
+		}, nil
+	})
+
+	voucherTypes, err := client.GetVoucherTypes(context.Background(), false)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if vouchersWhere != nil || len(voucherTypes) != 2 || voucherTypes[0].ActiveVouchers != nil {
+		t.Errorf("expected the active vouchers not to be counted, got %+v", voucherTypes)
+	}
+
+	voucherTypes, err = client.GetVoucherTypes(context.Background(), true)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if _, ok := vouchersWhere["expiration_gt"]; !ok {
+		t.Errorf("expected active vouchers to be filtered on expiration, got %v", vouchersWhere)
+	}
+
+	if len(voucherTypes) != 2 {
+		t.Fatalf("expected 2 voucher types, got %d", len(voucherTypes))
+	}
+
+	if *voucherTypes[0].ActiveVouchers != 2 || *voucherTypes[1].ActiveVouchers != 0 {
+		t.Errorf("unexpected active vouchers %d and %d", *voucherTypes[0].ActiveVouchers, *voucherTypes[1].ActiveVouchers)
+	}
+
+	if voucherTypes[0].Duration.Hours() != 720 || len(voucherTypes[0].EligibleAssets) != 1 {
+		t.Errorf("unexpected voucher type %+v", voucherTypes[0])
+	}
+}
+
+func TestGetVoucherStats(t *testing.T) {
+	client := newMockedClient(func(req *http.Request) (*http.Response, error) {
+		return newStatusResponse(200, `{"data": {"vouchers": [
+			{"id": "0x1", "voucherType": {"id": "0", "description": "small"}, "owner": {"id": "0xa"}, "expiration": "1738195200", "value": "0.1", "balance": "0.1"},
+			{"id": "0x2", "voucherType": {"id": "0", "description": "small"}, "owner": {"id": "0xb"}, "expiration": "1738368000", "value": "0.2", "balance": "0"},
+			{"id": "0x3", "voucherType": {"id": "1", "description": "large"}, "owner": {"id": "0xa"}, "expiration": "1735516800", "value": "10", "balance": "2.5"}
+		]}}`), nil
+	})
+
+	stats, err := client.GetVoucherStats(context.Background(), VoucherFilter{IncludeZeroBalance: true})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if stats.Total.Count != 3 || stats.Total.Value.String() != "10.3" || stats.Total.Balance.String() != "2.6" {
+		t.Errorf("unexpected total %+v", stats.Total)
+	}
+
+	if small := stats.ByType["0"]; small.Count != 2 || !small.Value.Equal(decimal.RequireFromString("0.3")) || stats.Types["0"] != "small" {
+		t.Errorf("unexpected type 0 totals %+v", small)
+	}
+
+	if owner := stats.ByOwner["0xa"]; owner.Count != 2 || owner.Balance.String() != "2.6" {
+		t.Errorf("unexpected owner totals %+v", owner)
+	}
+
+	// 2024-12-30 belongs to the first ISO week of 2025, 2025-01-30 and 2025-02-01 to the fifth
+	if len(stats.ByWeek) != 2 || stats.ByWeek["2025-W01"].Count != 1 || stats.ByWeek["2025-W05"].Count != 2 {
+		t.Errorf("unexpected weeks %+v", stats.ByWeek)
+	}
+
+	if len(stats.ByMonth) != 3 || stats.ByMonth["2024-12"].Count != 1 || stats.ByMonth["2025-02"].Count != 1 {
+		t.Errorf("unexpected months %+v", stats.ByMonth)
+	}
+
+	if utilisation := stats.ByType["1"].Utilisation(); utilisation.String() != "0.75" {
+		t.Errorf("expected a 0.75 utilisation, got %s", utilisation)
+	}
+
+	if utilisation := (VoucherTotals{}).Utilisation(); !utilisation.IsZero() {
+		t.Errorf("expected no utilisation without value, got %s", utilisation)
+	}
+}